@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+func demo(id int, wait *sync.WaitGroup) {
+	for i := 0; i < 100; i++ {
+		sum := i + 1
+		fmt.Sprintf("%d %d", id, sum)
+	}
+	wait.Done()
+}
+
+func main() {
+	wait := new(sync.WaitGroup)
+	wait.Add(2)
+	go demo(1, wait)
+	go demo(2, wait)
+	wait.Wait()
+}
@@ -0,0 +1,34 @@
+package main
+
+import "fmt"
+
+type point struct {
+	x, y int
+}
+
+func (p point) sum() int {
+	return p.x + p.y
+}
+
+func (p *point) move(dx int) int {
+	p.x += dx
+	return p.x
+}
+
+func add(a, b int) int {
+	return a + b
+}
+
+func scale(x float64, neg bool) float64 {
+	if neg {
+		return -x * 2
+	}
+	return x * 2
+}
+
+func main() {
+	x := 40
+	p := point{1, 2}
+	pp := &point{3, 4}
+	fmt.Println(add(x, 2), scale(1.5, false), p.sum(), pp.move(1))
+}
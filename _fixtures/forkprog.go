@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "child" {
+		fmt.Println("child", os.Getpid())
+		return
+	}
+	// syscall.ForkExec forks only once, os/exec may fork to check
+	// which system calls are available.
+	pid, err := syscall.ForkExec(os.Args[0], []string{os.Args[0], "child"}, &syscall.ProcAttr{
+		Files: []uintptr{0, 1, 2},
+	})
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	var ws syscall.WaitStatus
+	syscall.Wait4(pid, &ws, 0, nil)
+	fmt.Println("parent", os.Getpid(), ws.ExitStatus())
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func main() {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGUSR1)
+	syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+	select {
+	case <-c:
+		fmt.Println("received SIGUSR1")
+	case <-time.After(time.Second):
+		fmt.Println("SIGUSR1 not received")
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,17 @@
+package main
+
+import "fmt"
+
+type point struct {
+	X, Y, Z int
+}
+
+var p point
+
+func main() {
+	for i := 1; i <= 3; i++ {
+		p.Y += i
+	}
+	p.Z = 10
+	fmt.Println(p)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+)
+
+type FooBar struct {
+	Baz int
+	Bur string
+}
+
+var (
+	i1   = 1
+	i2   = 2
+	u8   = uint8(255)
+	f1   = 2.5
+	s1   = "hello world"
+	arr  = [5]int{1, 2, 3, 4, 5}
+	sl   = []int{10, 20, 30, 40}
+	fb   = FooBar{Baz: 3, Bur: "three"}
+	fbs  = []FooBar{{1, "a"}, {2, "b"}}
+	pfb  = &fb
+	pi   = &i1
+	parr = &arr
+	nilp *int
+	m1   = map[string]int{"one": 1, "two": 2}
+	m2   = map[int]*FooBar{1: {Baz: 1, Bur: "a"}}
+	m3   = map[string][40]int{"a": {3: 3}}
+	mbig = make(map[int]int)
+	mnil map[string]int
+	e1   error = &os.PathError{Op: "open", Path: "/nonexistent"}
+	enil error
+	if1  interface{} = fb
+	if2  interface{} = 5
+	if3  interface{} = pfb
+)
+
+var (
+	ch1 = make(chan int, 4)
+	ch2 = make(chan string)
+	ch3 = make(chan int)
+	chc = make(chan int, 2)
+	chn chan int
+)
+
+func main() {
+	// Enough entries for overflow buckets and a grown map.
+	for i := 0; i < 100; i++ {
+		mbig[i] = i * 2
+	}
+	// Wrap around the ring buffer of ch1.
+	for i := 1; i <= 4; i++ {
+		ch1 <- i
+	}
+	<-ch1
+	<-ch1
+	ch1 <- 5
+	chc <- 3
+	close(chc)
+	// Goroutines parked sending to ch2 and receiving from ch3.
+	for i := 0; i < 2; i++ {
+		go func() { ch2 <- "x" }()
+	}
+	go func() { <-ch3 }()
+	time.Sleep(100 * time.Millisecond)
+	runtime.Breakpoint()
+	fmt.Println(i1, i2, u8, f1, s1, arr, sl, fb, fbs, pfb, pi, parr, nilp, m1, m2, m3, mbig, mnil, e1, enil, if1, if2, if3, ch1, chc, chn)
+}
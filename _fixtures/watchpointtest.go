@@ -0,0 +1,12 @@
+package main
+
+import "fmt"
+
+var counter int
+
+func main() {
+	for i := 0; i < 3; i++ {
+		counter += i + 1
+	}
+	fmt.Println(counter)
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"net"
 	"os"
@@ -24,9 +26,12 @@ import (
 const version string = "0.9.0-alpha"
 
 var (
-	Log      bool
-	Headless bool
-	Addr     string
+	Log              bool
+	Headless         bool
+	Addr             string
+	FollowFork       string
+	MaxMapEntries    int
+	PanicBreakpoints bool
 )
 
 func main() {
@@ -43,11 +48,17 @@ Delve enables you to interact with your program by controlling the execution of
 evaluating variables, and providing information of thread / goroutine state, CPU register state and more.
 
 The goal of this tool is to provide a simple yet powerful interface for debugging Go programs.
+
+Breakpoints saved with the save-breakpoints command to .dlv/breakpoints.yml, relative to the
+working directory, are set again each time the terminal starts, including by attach and connect.
 `,
 	}
 	rootCommand.PersistentFlags().StringVarP(&Addr, "listen", "l", "localhost:0", "Debugging server listen address.")
 	rootCommand.PersistentFlags().BoolVarP(&Log, "log", "", false, "Enable debugging server logging.")
 	rootCommand.PersistentFlags().BoolVarP(&Headless, "headless", "", false, "Run debug server only, in headless mode.")
+	rootCommand.PersistentFlags().StringVarP(&FollowFork, "follow-fork", "", "parent", "Process to debug after a fork: parent, child or both.")
+	rootCommand.PersistentFlags().IntVarP(&MaxMapEntries, "max-map-entries", "", 64, "Number of entries of a map loaded when printing it.")
+	rootCommand.PersistentFlags().BoolVarP(&PanicBreakpoints, "panic-breakpoints", "", true, "Stop when the program does not recover from a panic or has a fatal error.")
 
 	// 'version' subcommand.
 	versionCommand := &cobra.Command{
@@ -79,10 +90,9 @@ starts and attaches to it, and enables you to immediately begin debugging your p
 		Run: func(cmd *cobra.Command, args []string) {
 			status := func() int {
 				const debugname = "debug"
-				goBuild := exec.Command("go", "build", "-o", debugname, "-gcflags", "-N -l")
-				goBuild.Stderr = os.Stderr
-				err := goBuild.Run()
-				if err != nil {
+				build := func() error { return gobuild(debugname) }
+				if err := build(); err != nil {
+					fmt.Fprintln(os.Stderr, err)
 					return 1
 				}
 				fp, err := filepath.Abs("./" + debugname)
@@ -93,7 +103,7 @@ starts and attaches to it, and enables you to immediately begin debugging your p
 				defer os.Remove(fp)
 
 				processArgs := append([]string{"./" + debugname}, args...)
-				return execute(0, processArgs, conf)
+				return execute(0, processArgs, build, conf)
 			}()
 			os.Exit(status)
 		},
@@ -105,7 +115,7 @@ starts and attaches to it, and enables you to immediately begin debugging your p
 		Use:   "exec [./path/to/binary]",
 		Short: "Runs precompiled binary, attaches and begins debug session.",
 		Run: func(cmd *cobra.Command, args []string) {
-			os.Exit(execute(0, args, conf))
+			os.Exit(execute(0, args, nil, conf))
 		},
 	}
 	rootCommand.AddCommand(execCommand)
@@ -121,10 +131,8 @@ starts and attaches to it, and enables you to immediately begin debugging your p
 				const debugname = "debug"
 				var processArgs []string
 				if traceAttachPid == 0 {
-					goBuild := exec.Command("go", "build", "-o", debugname, "-gcflags", "-N -l")
-					goBuild.Stderr = os.Stderr
-					err := goBuild.Run()
-					if err != nil {
+					if err := gobuild(debugname); err != nil {
+						fmt.Fprintln(os.Stderr, err)
 						return 1
 					}
 					fp, err := filepath.Abs("./" + debugname)
@@ -146,9 +154,12 @@ starts and attaches to it, and enables you to immediately begin debugging your p
 
 				// Create and start a debugger server
 				server := rpc.NewServer(&service.Config{
-					Listener:    listener,
-					ProcessArgs: processArgs,
-					AttachPid:   traceAttachPid,
+					Listener:           listener,
+					ProcessArgs:        processArgs,
+					AttachPid:          traceAttachPid,
+					FollowFork:         FollowFork,
+					MaxMapEntries:      MaxMapEntries,
+					NoPanicBreakpoints: !PanicBreakpoints,
 				}, Log)
 				if err := server.Run(); err != nil {
 					fmt.Fprintln(os.Stderr, err)
@@ -215,17 +226,15 @@ starts and attaches to it, and enable you to immediately begin debugging your pr
 					return 1
 				}
 				base := filepath.Base(wd)
-				goTest := exec.Command("go", "test", "-c", "-gcflags", "-N -l")
-				goTest.Stderr = os.Stderr
-				err = goTest.Run()
-				if err != nil {
+				if err := gotestbuild(); err != nil {
+					fmt.Fprintln(os.Stderr, err)
 					return 1
 				}
 				debugname := "./" + base + ".test"
 				defer os.Remove(debugname)
 				processArgs := append([]string{debugname}, args...)
 
-				return execute(0, processArgs, conf)
+				return execute(0, processArgs, gotestbuild, conf)
 			}()
 			os.Exit(status)
 		},
@@ -243,7 +252,7 @@ starts and attaches to it, and enable you to immediately begin debugging your pr
 				fmt.Fprintf(os.Stderr, "Invalid pid: %s\n", args[0])
 				os.Exit(1)
 			}
-			os.Exit(execute(pid, nil, conf))
+			os.Exit(execute(pid, nil, nil, conf))
 		},
 	}
 	rootCommand.AddCommand(attachCommand)
@@ -283,7 +292,33 @@ func connect(addr string, conf *config.Config) int {
 	return status
 }
 
-func execute(attachPid int, processArgs []string, conf *config.Config) int {
+// Compiles the program in the current directory with optimizations
+// disabled.
+func gobuild(debugname string) error {
+	return runBuild(exec.Command("go", "build", "-o", debugname, "-gcflags", "-N -l"))
+}
+
+// Compiles the test binary of the package in the current directory with
+// optimizations disabled.
+func gotestbuild() error {
+	return runBuild(exec.Command("go", "test", "-c", "-gcflags", "-N -l"))
+}
+
+// Runs a build command, if it fails the output of the compiler is
+// returned as the error.
+func runBuild(cmd *exec.Cmd) error {
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if stderr.Len() > 0 {
+			return errors.New(strings.TrimRight(stderr.String(), "\n"))
+		}
+		return err
+	}
+	return nil
+}
+
+func execute(attachPid int, processArgs []string, rebuild func() error, conf *config.Config) int {
 	// Make a TCP listener
 	listener, err := net.Listen("tcp", Addr)
 	if err != nil {
@@ -294,9 +329,13 @@ func execute(attachPid int, processArgs []string, conf *config.Config) int {
 
 	// Create and start a debugger server
 	server := rpc.NewServer(&service.Config{
-		Listener:    listener,
-		ProcessArgs: processArgs,
-		AttachPid:   attachPid,
+		Listener:           listener,
+		ProcessArgs:        processArgs,
+		AttachPid:          attachPid,
+		Rebuild:            rebuild,
+		FollowFork:         FollowFork,
+		MaxMapEntries:      MaxMapEntries,
+		NoPanicBreakpoints: !PanicBreakpoints,
 	}, Log)
 	if err := server.Run(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Name of the per-project breakpoints file, in the .dlv directory of the
+// working directory delve is started from, whatever the location of the
+// program being debugged.
+const projectBreakpointsFile string = "breakpoints.yml"
+
+// SavedBreakpoint is a breakpoint as stored in a breakpoints file.
+// The location is a location spec, like "main.go:10" or "main.main",
+// rather than an address so that it can still be resolved after the
+// program is rebuilt.
+type SavedBreakpoint struct {
+	Location    string   `yaml:"location"`
+	Tracepoint  bool     `yaml:"tracepoint,omitempty"`
+	Stacktrace  int      `yaml:"stacktrace,omitempty"`
+	Goroutine   bool     `yaml:"goroutine,omitempty"`
+	Variables   []string `yaml:"variables,omitempty"`
+	Commands    []string `yaml:"commands,omitempty"`
+	LogMessage  string   `yaml:"log,omitempty"`
+	Cond        string   `yaml:"cond,omitempty"`
+	IgnoreCount uint64   `yaml:"ignore,omitempty"`
+	StopEvery   uint64   `yaml:"every,omitempty"`
+	CreatedAt   string   `yaml:"created,omitempty"`
+	Disabled    bool     `yaml:"disabled,omitempty"`
+}
+
+// SaveBreakpoints writes bps to the breakpoints file at path.
+func SaveBreakpoints(path string, bps []SavedBreakpoint) error {
+	data, err := yaml.Marshal(bps)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// LoadBreakpoints reads the breakpoints file at path.
+func LoadBreakpoints(path string) ([]SavedBreakpoint, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var bps []SavedBreakpoint
+	if err := yaml.Unmarshal(data, &bps); err != nil {
+		return nil, err
+	}
+	return bps, nil
+}
+
+// ProjectBreakpointsFilePath returns the path of the per-project
+// breakpoints file, .dlv/breakpoints.yml relative to the working
+// directory.
+func ProjectBreakpointsFilePath() string {
+	return path.Join(configDir, projectBreakpointsFile)
+}
+
+// LoadProjectBreakpoints reads the per-project breakpoints file, if
+// there is one. A missing file is not an error.
+func LoadProjectBreakpoints() ([]SavedBreakpoint, error) {
+	bps, err := LoadBreakpoints(ProjectBreakpointsFilePath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return bps, err
+}
+
+// SaveProjectBreakpoints writes bps to the per-project breakpoints
+// file, creating its directory if needed.
+func SaveProjectBreakpoints(bps []SavedBreakpoint) error {
+	if err := os.MkdirAll(configDir, 0700); err != nil {
+		return err
+	}
+	return SaveBreakpoints(ProjectBreakpointsFilePath(), bps)
+}
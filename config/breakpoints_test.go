@@ -0,0 +1,67 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSaveLoadBreakpoints(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dlv-breakpoints")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	bps := []SavedBreakpoint{
+		{Location: "main.main"},
+		{
+			Location:    "/src/main.go:10",
+			Tracepoint:  true,
+			Stacktrace:  3,
+			Goroutine:   true,
+			Variables:   []string{"i", "s.f"},
+			Commands:    []string{"print i", "continue"},
+			LogMessage:  "i is {i}",
+			Cond:        "i == 2",
+			IgnoreCount: 4,
+			StopEvery:   5,
+			CreatedAt:   "main.go:20",
+			Disabled:    true,
+		},
+	}
+	path := filepath.Join(dir, "breakpoints.yml")
+	if err := SaveBreakpoints(path, bps); err != nil {
+		t.Fatalf("SaveBreakpoints(): %v", err)
+	}
+	loaded, err := LoadBreakpoints(path)
+	if err != nil {
+		t.Fatalf("LoadBreakpoints(): %v", err)
+	}
+	if !reflect.DeepEqual(loaded, bps) {
+		t.Fatalf("breakpoints changed by a save and load:\n%#v\nexpected:\n%#v", loaded, bps)
+	}
+}
+
+func TestLoadProjectBreakpointsMissing(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dlv-breakpoints")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	bps, err := LoadProjectBreakpoints()
+	if err != nil || bps != nil {
+		t.Fatalf("LoadProjectBreakpoints() without a project file: %v %v", bps, err)
+	}
+}
@@ -1,6 +1,9 @@
 package proc
 
-import "fmt"
+import (
+	"fmt"
+	"go/ast"
+)
 
 // Represents a single breakpoint. Stores information on the break
 // point including the byte of data that originally was stored at that
@@ -15,18 +18,58 @@ type Breakpoint struct {
 	OriginalData []byte // If software breakpoint, the data we replace with breakpoint instruction.
 	ID           int    // Monotonically increasing ID.
 	Temp         bool   // Whether this is a temp breakpoint (for next'ing).
+	Name         string // Name of breakpoints set by delve itself, like UnrecoveredPanic.
+	Enabled      bool   // Whether the breakpoint instruction is written to memory.
 
 	// Breakpoint information
 	Tracepoint bool     // Tracepoint flag
 	Stacktrace int      // Number of stack frames to retrieve
 	Goroutine  bool     // Retrieve goroutine information
 	Variables  []string // Variables to evaluate
+	Commands   []string // Terminal commands to run when the breakpoint stops the process
+	LogMessage string   // Message printed at each hit, with expressions between braces
+
+	// When Cond is not nil the breakpoint will only stop the process
+	// if evaluating Cond in the scope of the stopped thread yields true.
+	Cond ast.Expr
+
+	// When GoroutineID is not zero only the goroutine with that ID can
+	// hit the breakpoint.
+	GoroutineID int
+	// When CreatedAt is not nil only goroutines started by a go
+	// statement at that location can hit the breakpoint.
+	CreatedAt *GoStatementLocation
+	// When cfa is not zero the breakpoint only stops the process in the
+	// frame with that CFA, used to skip recursive calls.
+	cfa int64
+	// Temporary breakpoint set by a command like next at the address of
+	// this breakpoint, it can stop the process even if this breakpoint
+	// is disabled. See SetTempBreakpoint.
+	temp *Breakpoint
+
+	HitCount      map[int]uint64 // Number of times the breakpoint has been hit, by goroutine ID.
+	TotalHitCount uint64         // Number of times the breakpoint has been hit.
+	IgnoreCount   uint64         // Number of initial hits that will not stop the process.
+	StopEvery     uint64         // If not zero, only every StopEvery-th hit (after IgnoreCount) stops the process.
 }
 
 func (bp *Breakpoint) String() string {
 	return fmt.Sprintf("Breakpoint %d at %#v %s:%d", bp.ID, bp.Addr, bp.File, bp.Line)
 }
 
+// Internal reports whether the breakpoint was set by delve itself, like
+// the ones set by SetPanicBreakpoints.
+func (bp *Breakpoint) Internal() bool {
+	return bp.ID < 0
+}
+
+// Reports whether the breakpoint instruction is in memory, because the
+// breakpoint is enabled or because a temporary breakpoint shares its
+// address.
+func (bp *Breakpoint) active() bool {
+	return bp.Enabled || bp.temp != nil
+}
+
 // Clear this breakpoint appropriately depending on whether it is a
 // hardware or software breakpoint.
 func (bp *Breakpoint) Clear(thread *Thread) (*Breakpoint, error) {
@@ -36,6 +79,100 @@ func (bp *Breakpoint) Clear(thread *Thread) (*Breakpoint, error) {
 	return bp, nil
 }
 
+// Location of a go statement, used to restrict breakpoints to the
+// goroutines it creates.
+type GoStatementLocation struct {
+	Spec     string // Location spec the location was resolved from.
+	Function string
+	File     string
+	Line     int // If zero any go statement in Function matches.
+}
+
+func (loc *GoStatementLocation) match(dbp *Process, gopc uint64) bool {
+	file, line, fn := dbp.goSymTable.PCToLine(gopc)
+	if fn == nil {
+		return false
+	}
+	if loc.Line == 0 {
+		return fn.Name == loc.Function
+	}
+	return file == loc.File && line == loc.Line
+}
+
+// Reports whether the goroutine running on thread is one that can
+// hit the breakpoint.
+func (bp *Breakpoint) matchGoroutine(thread *Thread) (bool, error) {
+	if bp.GoroutineID == 0 && bp.CreatedAt == nil {
+		return true, nil
+	}
+	g, err := thread.GetG()
+	if err != nil {
+		return true, err
+	}
+	if g == nil {
+		// Not running a goroutine, for example on a system stack.
+		return false, nil
+	}
+	if bp.GoroutineID != 0 && g.Id != bp.GoroutineID {
+		return false, nil
+	}
+	if bp.CreatedAt != nil && !bp.CreatedAt.match(thread.dbp, g.GoPC) {
+		return false, nil
+	}
+	return true, nil
+}
+
+// Evaluates the breakpoint condition, if any, in the scope of the
+// goroutine that hit the breakpoint.
+func (bp *Breakpoint) checkCondition(thread *Thread) (bool, error) {
+	if bp.Cond == nil {
+		return true, nil
+	}
+	scope, err := thread.Scope()
+	if err != nil {
+		return true, err
+	}
+	met, err := scope.evalBoolean(bp.Cond)
+	if err != nil {
+		return true, fmt.Errorf("error evaluating condition of breakpoint %d: %s", bp.ID, err)
+	}
+	return met, nil
+}
+
+// Records a hit of the breakpoint by thread and reports whether the
+// process should stop there. Hits by goroutines filtered out by the
+// breakpoint and hits for which the condition is false are not counted.
+func (bp *Breakpoint) processHit(thread *Thread) (bool, error) {
+	match, err := bp.matchGoroutine(thread)
+	if err != nil || !match {
+		return match, err
+	}
+	if bp.cfa != 0 {
+		frames, err := thread.Stacktrace(0)
+		if err != nil {
+			return false, err
+		}
+		if frames[0].CFA != bp.cfa {
+			return false, nil
+		}
+	}
+	met, err := bp.checkCondition(thread)
+	if err != nil || !met {
+		return met, err
+	}
+	bp.TotalHitCount++
+	if g, err := thread.GetG(); err == nil {
+		bp.HitCount[g.Id]++
+	}
+	if bp.TotalHitCount <= bp.IgnoreCount {
+		return false, nil
+	}
+	if bp.StopEvery > 0 && (bp.TotalHitCount-bp.IgnoreCount)%bp.StopEvery != 0 {
+		return false, nil
+	}
+	return true, nil
+}
+
 // Returned when trying to set a breakpoint at
 // an address that already has a breakpoint set for it.
 type BreakpointExistsError struct {
@@ -74,6 +211,8 @@ func (dbp *Process) setBreakpoint(tid int, addr uint64, temp bool) (*Breakpoint,
 		Line:         l,
 		Addr:         addr,
 		Temp:         temp,
+		Enabled:      true,
+		HitCount:     map[int]uint64{},
 	}
 
 	if temp {
@@ -0,0 +1,131 @@
+package proc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A SyscallCatchpoint stops the process when a thread enters or
+// returns from one of a set of system calls. While there are syscall
+// catchpoints the threads are resumed with PTRACE_SYSCALL, which stops
+// them at every system call, so the process runs noticeably slower.
+type SyscallCatchpoint struct {
+	ID            int      // Monotonically increasing ID, shared with breakpoints.
+	Syscalls      []string // Names of the caught system calls, all of them if empty.
+	TotalHitCount uint64   // Number of times the catchpoint has been hit.
+
+	numbers map[int]bool
+}
+
+func (cp *SyscallCatchpoint) String() string {
+	if len(cp.Syscalls) == 0 {
+		return fmt.Sprintf("Catchpoint %d on all system calls", cp.ID)
+	}
+	return fmt.Sprintf("Catchpoint %d on system calls %s", cp.ID, strings.Join(cp.Syscalls, ", "))
+}
+
+// Reports whether the catchpoint catches the system call num.
+func (cp *SyscallCatchpoint) catches(num int) bool {
+	return len(cp.numbers) == 0 || cp.numbers[num]
+}
+
+// A SyscallStop describes the system call a thread is stopped at
+// because of a syscall catchpoint.
+type SyscallStop struct {
+	Catchpoint *SyscallCatchpoint
+	Number     int       // Number of the system call.
+	Name       string    // Name of the system call.
+	Args       [6]uint64 // Arguments of the system call, as passed in registers.
+	Exit       bool      // The thread is returning from the system call.
+	Return     int64     // Value returned by the system call, only set on exit.
+}
+
+func (s *SyscallStop) String() string {
+	args := make([]string, len(s.Args))
+	for i := range s.Args {
+		args[i] = fmt.Sprintf("%#x", s.Args[i])
+	}
+	call := fmt.Sprintf("%s(%s)", s.Name, strings.Join(args, ", "))
+	if s.Exit {
+		return fmt.Sprintf("%s = %d", call, s.Return)
+	}
+	return call
+}
+
+// SetSyscallCatchpoint sets a catchpoint on the system calls with the
+// given names, like "openat" or "connect", or on every system call if
+// names is empty.
+func (dbp *Process) SetSyscallCatchpoint(names []string) (*SyscallCatchpoint, error) {
+	cp := &SyscallCatchpoint{Syscalls: names, numbers: make(map[int]bool)}
+	for _, name := range names {
+		num, err := syscallNumber(name)
+		if err != nil {
+			return nil, err
+		}
+		cp.numbers[num] = true
+	}
+	dbp.breakpointIDCounter++
+	cp.ID = dbp.breakpointIDCounter
+	dbp.SyscallCatchpoints[cp.ID] = cp
+	return cp, nil
+}
+
+// Sets a syscall catchpoint with the specified ID, used to recreate the
+// catchpoints of a previous process.
+func (dbp *Process) SetSyscallCatchpointWithID(id int, names []string) (*SyscallCatchpoint, error) {
+	if _, ok := dbp.SyscallCatchpoints[id]; ok {
+		return nil, fmt.Errorf("catchpoint %d already exists", id)
+	}
+	counter := dbp.breakpointIDCounter
+	cp, err := dbp.SetSyscallCatchpoint(names)
+	if err != nil {
+		return nil, err
+	}
+	delete(dbp.SyscallCatchpoints, cp.ID)
+	cp.ID = id
+	dbp.SyscallCatchpoints[id] = cp
+	dbp.breakpointIDCounter = counter
+	if id > counter {
+		dbp.breakpointIDCounter = id
+	}
+	return cp, nil
+}
+
+// Clears the syscall catchpoint with the given ID.
+func (dbp *Process) ClearSyscallCatchpoint(id int) (*SyscallCatchpoint, error) {
+	cp, ok := dbp.SyscallCatchpoints[id]
+	if !ok {
+		return nil, fmt.Errorf("no catchpoint with id %d", id)
+	}
+	delete(dbp.SyscallCatchpoints, id)
+	return cp, nil
+}
+
+// Returns the system call the current thread is stopped at, if any.
+func (dbp *Process) CurrentSyscall() *SyscallStop {
+	return dbp.CurrentThread.CurrentSyscall
+}
+
+// Records the system call thread is stopped at and reports whether it
+// is caught by a catchpoint. If several catchpoints catch it the one
+// with the lowest ID is hit.
+func (thread *Thread) processSyscallStop() (bool, error) {
+	s, err := thread.syscallStop()
+	if err != nil {
+		return false, err
+	}
+	for _, cp := range thread.dbp.SyscallCatchpoints {
+		if !cp.catches(s.Number) {
+			continue
+		}
+		if s.Catchpoint == nil || cp.ID < s.Catchpoint.ID {
+			s.Catchpoint = cp
+		}
+	}
+	if s.Catchpoint == nil {
+		return false, nil
+	}
+	s.Catchpoint.TotalHitCount++
+	thread.CurrentSyscall = s
+	return true, nil
+}
@@ -0,0 +1,11 @@
+package proc
+
+import "fmt"
+
+func syscallNumber(name string) (int, error) {
+	return 0, fmt.Errorf("syscall catchpoints are only supported on linux")
+}
+
+func (thread *Thread) syscallStop() (*SyscallStop, error) {
+	return nil, fmt.Errorf("syscall catchpoints are only supported on linux")
+}
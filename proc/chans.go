@@ -0,0 +1,208 @@
+package proc
+
+import (
+	"debug/dwarf"
+	"fmt"
+	"strings"
+)
+
+// Reports whether v is a channel. Channels are pointers to a runtime
+// channel, described in the debug info by a struct named hchan<T>.
+func (v *Variable) isChan() bool {
+	t, ok := resolveTypedef(v.dwarfType).(*dwarf.PtrType)
+	if !ok {
+		return false
+	}
+	st, ok := resolveTypedef(t.Type).(*dwarf.StructType)
+	return ok && strings.HasPrefix(st.StructName, "hchan<")
+}
+
+// State of a channel, read from its runtime hchan.
+type chanState struct {
+	hchan    *Variable
+	len, cap int64
+	closed   bool
+	// Ring buffer of the buffered elements, the first one is at recvx.
+	buf      uintptr
+	recvx    int64
+	elemType dwarf.Type
+}
+
+// Returns the state of the channel v, or nil if v is a nil channel.
+func (v *Variable) chanState() (*chanState, error) {
+	h, err := v.maybeDereference()
+	if err != nil {
+		return nil, err
+	}
+	if h.Addr == 0 {
+		return nil, nil
+	}
+	h.Name = v.Name
+
+	var vals [5]uint64
+	for i, name := range []string{"qcount", "dataqsiz", "closed", "buf", "recvx"} {
+		f, err := h.structMember(name)
+		if err != nil {
+			return nil, fmt.Errorf("unsupported channel layout: %s", err)
+		}
+		if vals[i], err = v.thread.readUintRaw(f.Addr, f.dwarfType.Size()); err != nil {
+			return nil, err
+		}
+	}
+	elemType, err := chanElemType(h.resolveTypedefs().dwarfType.(*dwarf.StructType))
+	if err != nil {
+		return nil, err
+	}
+	return &chanState{
+		hchan:    h,
+		len:      int64(vals[0]),
+		cap:      int64(vals[1]),
+		closed:   vals[2] != 0,
+		buf:      uintptr(vals[3]),
+		recvx:    int64(vals[4]),
+		elemType: elemType,
+	}, nil
+}
+
+// Returns the element type of the runtime channel t, only found in the
+// debug info as the type pointed to by the elem field of the sudog<T> of
+// its wait queues.
+func chanElemType(t *dwarf.StructType) (dwarf.Type, error) {
+	typ := dwarf.Type(t)
+	for _, name := range []string{"recvq", "first", "elem"} {
+		if pt, ok := resolveTypedef(typ).(*dwarf.PtrType); ok {
+			typ = pt.Type
+		}
+		st, ok := resolveTypedef(typ).(*dwarf.StructType)
+		if !ok {
+			return nil, fmt.Errorf("unsupported channel layout: %s", t.StructName)
+		}
+		typ = nil
+		for _, field := range st.Field {
+			if field.Name == name {
+				typ = field.Type
+				break
+			}
+		}
+		if typ == nil {
+			return nil, fmt.Errorf("unsupported channel layout: %s has no member %s", st.StructName, name)
+		}
+	}
+	pt, ok := resolveTypedef(typ).(*dwarf.PtrType)
+	if !ok || pt.Type == nil {
+		return nil, fmt.Errorf("unsupported channel layout: %s", t.StructName)
+	}
+	return pt.Type, nil
+}
+
+// Returns the i-th buffered element of the channel, in receive order.
+func (st *chanState) elem(i int64) (*Variable, error) {
+	idx := (st.recvx + i) % st.cap
+	return newVariable("", st.buf+uintptr(idx*st.elemType.Size()), st.elemType, st.hchan.thread)
+}
+
+// Returns the IDs of the goroutines parked in the wait queue of the
+// channel named queue, recvq or sendq.
+func (st *chanState) waiting(queue string) ([]int, error) {
+	q, err := st.hchan.structMember(queue)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported channel layout: %s", err)
+	}
+	first, err := q.structMember("first")
+	if err != nil {
+		return nil, fmt.Errorf("unsupported channel layout: %s", err)
+	}
+	t, ok := resolveTypedef(first.dwarfType).(*dwarf.PtrType)
+	if !ok {
+		return nil, fmt.Errorf("unsupported channel layout: %s of type %s", queue, first.dwarfType)
+	}
+
+	thread := st.hchan.thread
+	ptrSize := int64(thread.dbp.arch.PtrSize())
+	addr, err := thread.readUintRaw(first.Addr, ptrSize)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, 0)
+	seen := make(map[uint64]bool)
+	for addr != 0 && !seen[addr] {
+		seen[addr] = true
+		sg, err := newVariable("", uintptr(addr), t.Type, thread)
+		if err != nil {
+			return nil, err
+		}
+		gv, err := sg.structMember("g")
+		if err != nil {
+			return nil, fmt.Errorf("unsupported channel layout: %s", err)
+		}
+		gaddr, err := thread.readUintRaw(gv.Addr, ptrSize)
+		if err != nil {
+			return nil, err
+		}
+		if gaddr != 0 {
+			goid, err := gv.structMember("goid")
+			if err != nil {
+				return nil, fmt.Errorf("unsupported channel layout: %s", err)
+			}
+			id, err := thread.readIntRaw(goid.Addr, goid.dwarfType.Size())
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, int(id))
+		}
+		next, err := sg.structMember("next")
+		if err != nil {
+			return nil, fmt.Errorf("unsupported channel layout: %s", err)
+		}
+		if addr, err = thread.readUintRaw(next.Addr, ptrSize); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+// Formats a channel as its length, capacity, closed state and buffered
+// elements followed by the IDs of the goroutines parked receiving from
+// and sending to it.
+func (v *Variable) loadChan(recurseLevel int) (string, error) {
+	st, err := v.chanState()
+	if err != nil {
+		return "", err
+	}
+	if st == nil {
+		return fmt.Sprintf("%s nil", v.Type), nil
+	}
+
+	vals := make([]string, 0)
+	errcount := 0
+	for i := int64(0); i < st.len; i++ {
+		if i >= maxArrayValues {
+			vals = append(vals, fmt.Sprintf("...+%d more", st.len-maxArrayValues))
+			break
+		}
+		var val string
+		elemvar, err := st.elem(i)
+		if err == nil {
+			val, err = elemvar.loadValueInternal(false, recurseLevel+1)
+		}
+		if err != nil {
+			errcount++
+			val = fmt.Sprintf("<unreadable: %s>", err.Error())
+		}
+		vals = append(vals, val)
+		if errcount > maxErrCount {
+			vals = append(vals, fmt.Sprintf("...+%d more", st.len-i))
+			break
+		}
+	}
+
+	recvq, err := st.waiting("recvq")
+	if err != nil {
+		return "", err
+	}
+	sendq, err := st.waiting("sendq")
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s len: %d, cap: %d, closed: %t, [%s], recvq: %v, sendq: %v", v.Type, st.len, st.cap, st.closed, strings.Join(vals, ","), recvq, sendq), nil
+}
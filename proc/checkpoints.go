@@ -0,0 +1,127 @@
+package proc
+
+import "fmt"
+
+// A Checkpoint is a copy of the process, made while it was stopped,
+// that can be restarted to go back to the state the process had then.
+type Checkpoint struct {
+	ID    int
+	PC    uint64 // PC of the current thread when the checkpoint was taken.
+	Where string // Description of the checkpoint, its location by default.
+
+	pid int // Pid of the stopped copy of the process.
+	// Original data of the breakpoints written to the memory of the copy.
+	breakpoints map[uint64][]byte
+}
+
+// Checkpoints returns the checkpoints of the process, in the order they
+// were taken.
+func (dbp *Process) Checkpoints() []*Checkpoint {
+	return dbp.checkpoints
+}
+
+// Checkpoint saves the state of the process in a copy of it, made by
+// running a fork system call on the current thread, and returns its ID.
+// The copy stays stopped until it is restarted with RestartCheckpoint.
+// Only the current thread exists in the copy, since fork does not copy
+// the other threads of the process.
+func (dbp *Process) Checkpoint(where string) (int, error) {
+	if dbp.exited {
+		return 0, fmt.Errorf("process has already exited")
+	}
+	if dbp.Running() {
+		return 0, fmt.Errorf("process must be stopped to take a checkpoint")
+	}
+	thread := dbp.CurrentThread
+	if thread.blocked() {
+		return 0, fmt.Errorf("can not take a checkpoint while the current thread is blocked in a system call")
+	}
+	pc, err := thread.PC()
+	if err != nil {
+		return 0, err
+	}
+	if where == "" {
+		f, l, fn := dbp.PCToLine(pc)
+		where = fmt.Sprintf("%s:%d", f, l)
+		if fn != nil {
+			where = fmt.Sprintf("%s() %s", fn.Name, where)
+		}
+	}
+	pid, err := dbp.fork(thread)
+	if err != nil {
+		return 0, err
+	}
+	dbp.checkpointIDCounter++
+	cp := &Checkpoint{
+		ID:          dbp.checkpointIDCounter,
+		PC:          pc,
+		Where:       where,
+		pid:         pid,
+		breakpoints: make(map[uint64][]byte),
+	}
+	for addr, bp := range dbp.Breakpoints {
+		if bp.Enabled {
+			cp.breakpoints[addr] = bp.OriginalData
+		}
+	}
+	dbp.checkpoints = append(dbp.checkpoints, cp)
+	return cp.ID, nil
+}
+
+// RestartCheckpoint kills the process and continues debugging a copy of
+// the checkpoint with the given ID, with the current breakpoints. The
+// checkpoint itself is left untouched so that it can be restarted again.
+func (dbp *Process) RestartCheckpoint(id int) error {
+	var cp *Checkpoint
+	for i := range dbp.checkpoints {
+		if dbp.checkpoints[i].ID == id {
+			cp = dbp.checkpoints[i]
+		}
+	}
+	if cp == nil {
+		return fmt.Errorf("no checkpoint with id %d", id)
+	}
+	if !dbp.exited && dbp.Running() {
+		return fmt.Errorf("process must be stopped to restart a checkpoint")
+	}
+	pid, err := dbp.fork(dbp.checkpointThread(cp))
+	if err != nil {
+		return err
+	}
+	if !dbp.exited {
+		if err := dbp.killProcess(); err != nil {
+			return err
+		}
+	}
+	if err := dbp.switchProcess(pid); err != nil {
+		return err
+	}
+
+	// The memory of the copy has the breakpoints that existed when the
+	// checkpoint was taken.
+	thread := dbp.CurrentThread
+	for addr, data := range cp.breakpoints {
+		if _, err := thread.writeMemory(uintptr(addr), data); err != nil {
+			return err
+		}
+	}
+	for addr, bp := range dbp.Breakpoints {
+		if !bp.Enabled {
+			continue
+		}
+		if err := dbp.writeSoftwareBreakpoint(thread, addr); err != nil {
+			return err
+		}
+	}
+	pc, err := thread.PC()
+	if err != nil {
+		return err
+	}
+	thread.CurrentBreakpoint, _ = dbp.findEnabledBreakpoint(pc)
+	return nil
+}
+
+// Returns a thread for the stopped copy of the checkpoint.
+func (dbp *Process) checkpointThread(cp *Checkpoint) *Thread {
+	return &Thread{Id: cp.pid, dbp: dbp, os: new(OSSpecificDetails)}
+}
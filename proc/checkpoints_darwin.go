@@ -0,0 +1,20 @@
+package proc
+
+import "fmt"
+
+func (dbp *Process) fork(thread *Thread) (int, error) {
+	return 0, fmt.Errorf("checkpoints are only supported on linux")
+}
+
+func (dbp *Process) killProcess() error {
+	return fmt.Errorf("checkpoints are only supported on linux")
+}
+
+func (dbp *Process) switchProcess(pid int) error {
+	return fmt.Errorf("checkpoints are only supported on linux")
+}
+
+// There are no checkpoints to clear.
+func (dbp *Process) clearCheckpoints() error {
+	return nil
+}
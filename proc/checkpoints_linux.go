@@ -0,0 +1,154 @@
+package proc
+
+import (
+	"fmt"
+	"os"
+
+	sys "golang.org/x/sys/unix"
+)
+
+// The syscall instruction, written at the PC of the thread calling fork.
+var syscallInstruction = []byte{0x0f, 0x05}
+
+// Makes thread call fork and returns the pid of the child, which is
+// traced and left stopped with the memory and registers thread had
+// before the call. The state of thread is restored as well.
+func (dbp *Process) fork(thread *Thread) (pid int, err error) {
+	regs, err := thread.saveRegisters()
+	if err != nil {
+		return 0, err
+	}
+	pc := regs.PC()
+	orig, err := thread.readMemory(uintptr(pc), len(syscallInstruction))
+	if err != nil {
+		return 0, err
+	}
+	if _, err := thread.writeMemory(uintptr(pc), syscallInstruction); err != nil {
+		return 0, err
+	}
+	defer func() {
+		if _, werr := thread.writeMemory(uintptr(pc), orig); werr != nil && err == nil {
+			err = werr
+		}
+		if rerr := thread.restoreRegisters(); rerr != nil && err == nil {
+			err = rerr
+		}
+	}()
+
+	callRegs := thread.os.registers
+	callRegs.Rax = sys.SYS_FORK
+	dbp.execPtraceFunc(func() { err = sys.PtraceSetRegs(thread.Id, &callRegs) })
+	if err != nil {
+		return 0, err
+	}
+	// The child of a traced fork is traced as well and starts stopped.
+	dbp.execPtraceFunc(func() { err = sys.PtraceSetOptions(thread.Id, dbp.ptraceOptions()|sys.PTRACE_O_TRACEFORK) })
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		var oerr error
+		dbp.execPtraceFunc(func() { oerr = sys.PtraceSetOptions(thread.Id, dbp.ptraceOptions()) })
+		if oerr != nil && err == nil {
+			err = oerr
+		}
+	}()
+
+	// The first step stops at the fork event, the second one completes
+	// the system call.
+	var child uint
+	defer func() {
+		if err != nil && child != 0 {
+			sys.Kill(int(child), sys.SIGKILL)
+		}
+	}()
+	for i := 0; i < 2; i++ {
+		dbp.execPtraceFunc(func() { err = PtraceSingleStep(thread.Id) })
+		if err != nil {
+			return 0, err
+		}
+		_, status, err := wait(thread.Id, dbp.Pid, 0)
+		if err != nil {
+			return 0, err
+		}
+		if status == nil || status.Exited() {
+			return 0, fmt.Errorf("thread %d exited while calling fork", thread.Id)
+		}
+		if status.StopSignal() != sys.SIGTRAP {
+			// A signal received while stopped, like the SIGCHLD sent to
+			// a checkpoint when a copy made from it exits, it is
+			// discarded by stepping again.
+			i--
+			continue
+		}
+		if i == 0 {
+			if status.TrapCause() != sys.PTRACE_EVENT_FORK {
+				return 0, fmt.Errorf("fork failed on thread %d", thread.Id)
+			}
+			dbp.execPtraceFunc(func() { child, err = sys.PtraceGetEventMsg(thread.Id) })
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	pid = int(child)
+	if _, _, err := wait(pid, pid, 0); err != nil {
+		return 0, err
+	}
+	childThread := &Thread{Id: pid, dbp: dbp, os: new(OSSpecificDetails)}
+	if _, err := childThread.writeMemory(uintptr(pc), orig); err != nil {
+		return 0, err
+	}
+	dbp.execPtraceFunc(func() { err = sys.PtraceSetRegs(pid, &thread.os.registers) })
+	return pid, err
+}
+
+// Kills the process, without killing the other processes of its group.
+func (dbp *Process) killProcess() error {
+	if err := sys.Kill(dbp.Pid, sys.SIGKILL); err != nil {
+		return fmt.Errorf("could not deliver signal %s", err)
+	}
+	if _, _, err := wait(dbp.Pid, dbp.Pid, 0); err != nil {
+		return err
+	}
+	dbp.exited = true
+	return nil
+}
+
+// Starts debugging the stopped process pid, a copy of the process made
+// with fork, in place of the current process.
+func (dbp *Process) switchProcess(pid int) error {
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	dbp.Pid = pid
+	dbp.Process = proc
+	dbp.Threads = make(map[int]*Thread)
+	dbp.CurrentThread = nil
+	dbp.SelectedGoroutine = nil
+	dbp.allGCache = nil
+	dbp.halt = false
+	dbp.haltThread = nil
+	dbp.exited = false
+	if _, err := dbp.addThread(pid, false); err != nil {
+		return err
+	}
+	dbp.SelectedGoroutine, _ = dbp.CurrentThread.GetG()
+	return nil
+}
+
+// Kills the stopped copies of all checkpoints.
+func (dbp *Process) clearCheckpoints() error {
+	for _, cp := range dbp.checkpoints {
+		if err := sys.Kill(cp.pid, sys.SIGKILL); err != nil {
+			return fmt.Errorf("could not kill checkpoint %d: %s", cp.ID, err)
+		}
+		if _, _, err := wait(cp.pid, cp.pid, 0); err != nil {
+			return err
+		}
+	}
+	dbp.checkpoints = nil
+	return nil
+}
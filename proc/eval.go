@@ -0,0 +1,860 @@
+package proc
+
+import (
+	"bytes"
+	"debug/dwarf"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"math"
+	"strconv"
+)
+
+// Types of the values computed by the evaluator which do not come from
+// the debug info: len and cap return an int, indexing a string returns
+// a byte.
+var (
+	intType  = &dwarf.IntType{BasicType: dwarf.BasicType{CommonType: dwarf.CommonType{ByteSize: 8, Name: "int"}}}
+	byteType = &dwarf.UintType{BasicType: dwarf.BasicType{CommonType: dwarf.CommonType{ByteSize: 1, Name: "uint8"}}}
+)
+
+// Evaluates expr in the current scope, expr must be a boolean expression.
+func (scope *EvalScope) evalBoolean(expr ast.Expr) (bool, error) {
+	v, err := scope.evalConstant(expr)
+	if err != nil {
+		return false, err
+	}
+	if v.Kind() != constant.Bool {
+		return false, fmt.Errorf("expression %s is not boolean", exprToString(expr))
+	}
+	return constant.BoolVal(v), nil
+}
+
+// Evaluates expr to a constant value, variables referenced by expr are
+// read from the target process.
+func (scope *EvalScope) evalConstant(expr ast.Expr) (constant.Value, error) {
+	v, err := scope.evalAST(expr)
+	if err != nil {
+		return nil, err
+	}
+	return v.constantValue()
+}
+
+// Evaluates expr in the current scope. The result is either a variable
+// stored in the memory of the target process, like a[3] or *p, or a
+// value computed by the evaluator, like x+1 or len(s), which can be
+// read but not assigned to.
+func (scope *EvalScope) evalAST(expr ast.Expr) (*Variable, error) {
+	switch node := expr.(type) {
+	case *ast.ParenExpr:
+		return scope.evalAST(node.X)
+
+	case *ast.BasicLit:
+		c := constant.MakeFromLiteral(node.Value, node.Kind, 0)
+		if c.Kind() == constant.Unknown {
+			return nil, fmt.Errorf("invalid literal %s", node.Value)
+		}
+		return scope.newConstant(node.Value, c, nil), nil
+
+	case *ast.Ident:
+		return scope.evalIdent(node)
+
+	case *ast.SelectorExpr:
+		return scope.evalSelector(node)
+
+	case *ast.IndexExpr:
+		return scope.evalIndex(node)
+
+	case *ast.SliceExpr:
+		return scope.evalReslice(node)
+
+	case *ast.StarExpr:
+		return scope.evalPointerDeref(node)
+
+	case *ast.UnaryExpr:
+		if node.Op == token.AND {
+			return scope.evalAddrOf(node)
+		}
+		return scope.evalUnary(node)
+
+	case *ast.BinaryExpr:
+		return scope.evalBinary(node)
+
+	case *ast.CallExpr:
+		return scope.evalCall(node)
+
+	case *ast.TypeAssertExpr:
+		return scope.evalTypeAssert(node)
+	}
+
+	return nil, fmt.Errorf("expression %s not supported", exprToString(expr))
+}
+
+// Returns a value computed by the evaluator, of type typ or untyped if
+// typ is nil.
+func (scope *EvalScope) newConstant(name string, c constant.Value, typ dwarf.Type) *Variable {
+	v := &Variable{Name: name, value: c, dwarfType: typ, thread: scope.Thread}
+	if typ != nil {
+		v.Type = typ.String()
+	} else {
+		v.Type = "untyped " + constantKindName(c.Kind())
+	}
+	return v
+}
+
+func constantKindName(kind constant.Kind) string {
+	switch kind {
+	case constant.Bool:
+		return "bool"
+	case constant.String:
+		return "string"
+	case constant.Int:
+		return "int"
+	case constant.Float:
+		return "float"
+	case constant.Complex:
+		return "complex"
+	}
+	return "unknown"
+}
+
+func (scope *EvalScope) evalIdent(node *ast.Ident) (*Variable, error) {
+	switch node.Name {
+	case "true", "false":
+		return scope.newConstant(node.Name, constant.MakeBool(node.Name == "true"), nil), nil
+	case "nil":
+		v := scope.newConstant(node.Name, constant.MakeUint64(0), nil)
+		v.Type = "untyped nil"
+		return v, nil
+	}
+
+	v, err := scope.extractVarInfo(node.Name)
+	if err == nil {
+		return v, nil
+	}
+	// Attempt to evaluate name as a package variable of the current package.
+	if pkg := scope.packageName(); pkg != "" {
+		if pv, perr := scope.packageVarAddr(pkg + "." + node.Name); perr == nil {
+			pv.Name = node.Name
+			return pv, nil
+		}
+	}
+	return nil, err
+}
+
+func (scope *EvalScope) evalSelector(node *ast.SelectorExpr) (*Variable, error) {
+	x, err := scope.evalAST(node.X)
+	if err != nil {
+		// Attempt to evaluate node as a package variable, like main.p1.
+		if _, ok := node.X.(*ast.Ident); ok {
+			if v, perr := scope.packageVarAddr(exprToString(node)); perr == nil {
+				return v, nil
+			}
+		}
+		return nil, err
+	}
+	if x.dwarfType == nil {
+		return nil, fmt.Errorf("%s has no member %s", x.Name, node.Sel.Name)
+	}
+	return x.structMember(node.Sel.Name)
+}
+
+func (scope *EvalScope) evalIndex(node *ast.IndexExpr) (*Variable, error) {
+	x, err := scope.evalAST(node.X)
+	if err != nil {
+		return nil, err
+	}
+	if x, err = x.derefArrayPointer(); err != nil {
+		return nil, err
+	}
+	if x.isMap() {
+		return scope.evalMapIndex(node, x)
+	}
+	n, err := scope.evalInt(node.Index)
+	if err != nil {
+		return nil, err
+	}
+	name := exprToString(node)
+
+	if x.isString() {
+		s, err := x.stringSlice(n, n+1)
+		if err != nil {
+			return nil, err
+		}
+		return scope.newConstant(name, constant.MakeUint64(uint64(s[0])), byteType), nil
+	}
+	if !x.isArrayOrSlice() {
+		return nil, fmt.Errorf("invalid operation: %s (type %s does not support indexing)", exprToString(node), x.Type)
+	}
+	if n < 0 || n >= x.Len {
+		return nil, fmt.Errorf("index %d out of range [0:%d]", n, x.Len)
+	}
+	return newVariable(name, uintptr(int64(x.base)+n*x.stride), x.fieldType, x.thread)
+}
+
+func (scope *EvalScope) evalReslice(node *ast.SliceExpr) (*Variable, error) {
+	x, err := scope.evalAST(node.X)
+	if err != nil {
+		return nil, err
+	}
+	if x, err = x.derefArrayPointer(); err != nil {
+		return nil, err
+	}
+
+	var length, capacity int64
+	switch {
+	case x.isString():
+		if node.Slice3 {
+			return nil, fmt.Errorf("invalid operation: 3-index slice of string")
+		}
+		if length, err = x.stringLen(); err != nil {
+			return nil, err
+		}
+		capacity = length
+	case x.isArrayOrSlice():
+		length, capacity = x.Len, x.Cap
+		if capacity < 0 {
+			// Arrays.
+			capacity = length
+		}
+	default:
+		return nil, fmt.Errorf("can not slice %s (type %s)", exprToString(node.X), x.Type)
+	}
+
+	low, high, max := int64(0), length, capacity
+	if node.Low != nil {
+		if low, err = scope.evalInt(node.Low); err != nil {
+			return nil, err
+		}
+	}
+	if node.High != nil {
+		if high, err = scope.evalInt(node.High); err != nil {
+			return nil, err
+		}
+	}
+	if node.Max != nil {
+		if max, err = scope.evalInt(node.Max); err != nil {
+			return nil, err
+		}
+	}
+	if low < 0 || low > high || high > max || max > capacity {
+		return nil, fmt.Errorf("slice bounds out of range [%d:%d:%d] with capacity %d", low, high, max, capacity)
+	}
+	name := exprToString(node)
+
+	if x.isString() {
+		s, err := x.stringSlice(low, high)
+		if err != nil {
+			return nil, err
+		}
+		return scope.newConstant(name, constant.MakeString(s), x.dwarfType), nil
+	}
+
+	typ := x.dwarfType
+	if _, isarray := resolveTypedef(typ).(*dwarf.ArrayType); isarray {
+		typ = scope.sliceType(x.fieldType)
+	}
+	return &Variable{
+		Name:      name,
+		Type:      typ.String(),
+		dwarfType: typ,
+		thread:    x.thread,
+		Len:       high - low,
+		Cap:       max - low,
+		base:      uintptr(int64(x.base) + low*x.stride),
+		stride:    x.stride,
+		fieldType: x.fieldType,
+	}, nil
+}
+
+// Returns the type of slices of elem, as found in the debug info if the
+// program uses it.
+func (scope *EvalScope) sliceType(elem dwarf.Type) dwarf.Type {
+	elemName := elem.Common().Name
+	if elemName == "" {
+		elemName = elem.String()
+	}
+	if t, err := scope.Thread.dbp.findType("[]" + elemName); err == nil {
+		return t
+	}
+	return &dwarf.StructType{
+		CommonType: dwarf.CommonType{ByteSize: int64(3 * scope.PtrSize()), Name: "[]" + elemName},
+		StructName: "[]" + elemName,
+		Kind:       "struct",
+	}
+}
+
+func (scope *EvalScope) evalPointerDeref(node *ast.StarExpr) (*Variable, error) {
+	x, err := scope.evalAST(node.X)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := resolveTypedef(x.dwarfType).(*dwarf.PtrType); !ok {
+		return nil, fmt.Errorf("invalid indirect of %s (type %s)", exprToString(node.X), x.Type)
+	}
+	v, err := x.maybeDereference()
+	if err != nil {
+		return nil, err
+	}
+	if v.Addr == 0 {
+		return nil, fmt.Errorf("%s is nil", exprToString(node.X))
+	}
+	v.Name = exprToString(node)
+	return v, nil
+}
+
+func (scope *EvalScope) evalAddrOf(node *ast.UnaryExpr) (*Variable, error) {
+	x, err := scope.evalAST(node.X)
+	if err != nil {
+		return nil, err
+	}
+	if !x.addressable() {
+		return nil, fmt.Errorf("can not take the address of %s", exprToString(node.X))
+	}
+	typ := &dwarf.PtrType{CommonType: dwarf.CommonType{ByteSize: int64(scope.PtrSize())}, Type: x.dwarfType}
+	return scope.newConstant(exprToString(node), constant.MakeUint64(uint64(x.Addr)), typ), nil
+}
+
+func (scope *EvalScope) evalUnary(node *ast.UnaryExpr) (*Variable, error) {
+	x, err := scope.evalAST(node.X)
+	if err != nil {
+		return nil, err
+	}
+	c, err := x.constantValue()
+	if err != nil {
+		return nil, err
+	}
+
+	var prec uint
+	switch node.Op {
+	case token.NOT:
+		if c.Kind() != constant.Bool {
+			return nil, fmt.Errorf("operator ! not defined on %s", exprToString(node.X))
+		}
+	case token.XOR:
+		if c.Kind() != constant.Int {
+			return nil, fmt.Errorf("operator %s not defined on %s", node.Op, exprToString(node.X))
+		}
+		// The complement of unsigned values is limited to their size.
+		if t, ok := resolveTypedef(x.dwarfType).(*dwarf.UintType); ok {
+			prec = uint(t.ByteSize * 8)
+		}
+	case token.SUB, token.ADD:
+		if !isNumericConstant(c) {
+			return nil, fmt.Errorf("operator %s not defined on %s", node.Op, exprToString(node.X))
+		}
+	default:
+		return nil, fmt.Errorf("operator %s not supported", node.Op)
+	}
+
+	r, err := convertConstant(constant.UnaryOp(node.Op, c, prec), x.dwarfType)
+	if err != nil {
+		return nil, err
+	}
+	return scope.newConstant(exprToString(node), r, x.dwarfType), nil
+}
+
+func (scope *EvalScope) evalBinary(node *ast.BinaryExpr) (*Variable, error) {
+	name := exprToString(node)
+	x, err := scope.evalAST(node.X)
+	if err != nil {
+		return nil, err
+	}
+	xc, err := x.constantValue()
+	if err != nil {
+		return nil, err
+	}
+
+	switch node.Op {
+	case token.LAND, token.LOR:
+		if xc.Kind() != constant.Bool {
+			return nil, fmt.Errorf("operator %s not defined on %s", node.Op, exprToString(node.X))
+		}
+		// Short circuit evaluation, the right operand could be unreadable.
+		if constant.BoolVal(xc) == (node.Op == token.LOR) {
+			return scope.newConstant(name, xc, nil), nil
+		}
+		yc, err := scope.evalConstant(node.Y)
+		if err != nil {
+			return nil, err
+		}
+		if yc.Kind() != constant.Bool {
+			return nil, fmt.Errorf("operator %s not defined on %s", node.Op, exprToString(node.Y))
+		}
+		return scope.newConstant(name, yc, nil), nil
+	}
+
+	y, err := scope.evalAST(node.Y)
+	if err != nil {
+		return nil, err
+	}
+	yc, err := y.constantValue()
+	if err != nil {
+		return nil, err
+	}
+
+	switch node.Op {
+	case token.SHL, token.SHR:
+		return scope.evalShift(node, x, xc, yc)
+	}
+
+	// As in Go typed operands must have the same type, untyped ones take
+	// the type of the other operand.
+	typ := x.dwarfType
+	switch {
+	case typ == nil:
+		typ = y.dwarfType
+	case y.dwarfType != nil && typ.String() != y.dwarfType.String():
+		return nil, fmt.Errorf("mismatched types %s and %s in %s", x.Type, y.Type, name)
+	}
+	if !compatibleConstants(xc, yc) {
+		return nil, fmt.Errorf("mismatched types in %s", name)
+	}
+
+	switch node.Op {
+	case token.LSS, token.LEQ, token.GTR, token.GEQ:
+		switch {
+		case xc.Kind() == constant.Bool, xc.Kind() == constant.Complex, yc.Kind() == constant.Complex:
+			return nil, fmt.Errorf("operator %s not defined on %s", node.Op, exprToString(node.X))
+		}
+		fallthrough
+	case token.EQL, token.NEQ:
+		return scope.newConstant(name, constant.MakeBool(constant.Compare(xc, node.Op, yc)), nil), nil
+	}
+
+	r, err := binaryOp(node, xc, yc)
+	if err != nil {
+		return nil, err
+	}
+	if r, err = convertConstant(r, typ); err != nil {
+		return nil, err
+	}
+	return scope.newConstant(name, r, typ), nil
+}
+
+// Applies the arithmetic operator of node to x and y.
+func binaryOp(node *ast.BinaryExpr, x, y constant.Value) (constant.Value, error) {
+	switch node.Op {
+	case token.ADD:
+		if x.Kind() == constant.String {
+			return constant.MakeString(constant.StringVal(x) + constant.StringVal(y)), nil
+		}
+		fallthrough
+	case token.SUB, token.MUL, token.QUO, token.REM:
+		if !isNumericConstant(x) {
+			return nil, fmt.Errorf("operator %s not defined on %s", node.Op, exprToString(node.X))
+		}
+		op := node.Op
+		isInt := x.Kind() == constant.Int && y.Kind() == constant.Int
+		if op == token.REM && !isInt {
+			return nil, fmt.Errorf("operator %% not defined on %s", exprToString(node.X))
+		}
+		if (op == token.QUO || op == token.REM) && constant.Sign(y) == 0 {
+			// constant.BinaryOp panics on any division by zero.
+			if isInt {
+				return nil, fmt.Errorf("integer divide by zero")
+			}
+			return nil, fmt.Errorf("division by zero")
+		}
+		if isInt && op == token.QUO {
+			// Integer division, as opposed to exact division.
+			op = token.QUO_ASSIGN
+		}
+		return constant.BinaryOp(x, op, y), nil
+	case token.AND, token.OR, token.XOR, token.AND_NOT:
+		if x.Kind() != constant.Int || y.Kind() != constant.Int {
+			return nil, fmt.Errorf("operator %s not defined on %s", node.Op, exprToString(node.X))
+		}
+		return constant.BinaryOp(x, node.Op, y), nil
+	}
+
+	return nil, fmt.Errorf("operator %s not supported", node.Op)
+}
+
+// Largest shift count of untyped constants, larger shifts of typed values
+// are clamped to the size of the value.
+const maxShiftCount = 512
+
+func (scope *EvalScope) evalShift(node *ast.BinaryExpr, x *Variable, xc, yc constant.Value) (*Variable, error) {
+	if xc.Kind() != constant.Int {
+		return nil, fmt.Errorf("operator %s not defined on %s", node.Op, exprToString(node.X))
+	}
+	s, ok := constant.Uint64Val(constant.ToInt(yc))
+	if !ok {
+		return nil, fmt.Errorf("invalid shift count %s", exprToString(node.Y))
+	}
+	if x.dwarfType != nil && s > 64 {
+		s = 64
+	}
+	if s > maxShiftCount {
+		return nil, fmt.Errorf("shift count %d too large", s)
+	}
+	r, err := convertConstant(constant.Shift(xc, node.Op, uint(s)), x.dwarfType)
+	if err != nil {
+		return nil, err
+	}
+	return scope.newConstant(exprToString(node), r, x.dwarfType), nil
+}
+
+func (scope *EvalScope) evalCall(node *ast.CallExpr) (*Variable, error) {
+	if fn, ok := node.Fun.(*ast.Ident); ok {
+		switch fn.Name {
+		case "len", "cap":
+			return scope.evalBuiltinCall(node, fn.Name)
+		}
+	}
+	typ, err := scope.findTypeExpr(node.Fun)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a type, use call to call functions", exprToString(node.Fun))
+	}
+	if len(node.Args) != 1 {
+		return nil, fmt.Errorf("wrong number of arguments in conversion to %s", exprToString(node.Fun))
+	}
+	x, err := scope.evalAST(node.Args[0])
+	if err != nil {
+		return nil, err
+	}
+	c, err := x.constantValue()
+	if err != nil {
+		return nil, err
+	}
+	if _, isptr := resolveTypedef(typ).(*dwarf.PtrType); isptr && c.Kind() != constant.Int {
+		return nil, fmt.Errorf("can not convert %s to %s", exprToString(node.Args[0]), typ)
+	}
+	r, err := convertConstant(c, typ)
+	if err != nil {
+		return nil, err
+	}
+	return scope.newConstant(exprToString(node), r, typ), nil
+}
+
+// Evaluates the type assertion node, x.(T) is the value stored in the
+// interface x if its dynamic type is T. Assertions to interface types
+// are not supported.
+func (scope *EvalScope) evalTypeAssert(node *ast.TypeAssertExpr) (*Variable, error) {
+	x, err := scope.evalAST(node.X)
+	if err != nil {
+		return nil, err
+	}
+	if !x.isInterface() {
+		return nil, fmt.Errorf("invalid type assertion: %s (non-interface type %s on left)", exprToString(node), x.Type)
+	}
+	if node.Type == nil {
+		return nil, fmt.Errorf("use of .(type) outside type switch")
+	}
+	typ, err := scope.findTypeExpr(node.Type)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a type", exprToString(node.Type))
+	}
+	if (&Variable{dwarfType: typ}).isInterface() {
+		return nil, fmt.Errorf("type assertion to interface type %s not supported", exprToString(node.Type))
+	}
+	data, err := x.interfaceValue()
+	if err != nil {
+		return nil, err
+	}
+	name := dwarfTypeName(typ)
+	if data == nil {
+		return nil, fmt.Errorf("interface conversion: interface is nil, not %s", name)
+	}
+	if data.Type != name {
+		return nil, fmt.Errorf("interface conversion: %s is %s, not %s", x.Type, data.Type, name)
+	}
+	data.Name = exprToString(node)
+	return data, nil
+}
+
+// Evaluates the builtins len and cap.
+func (scope *EvalScope) evalBuiltinCall(node *ast.CallExpr, builtin string) (*Variable, error) {
+	if len(node.Args) != 1 {
+		return nil, fmt.Errorf("wrong number of arguments to %s", builtin)
+	}
+	x, err := scope.evalAST(node.Args[0])
+	if err != nil {
+		return nil, err
+	}
+	if x, err = x.derefArrayPointer(); err != nil {
+		return nil, err
+	}
+
+	var n int64
+	switch {
+	case x.isArrayOrSlice():
+		n = x.Len
+		if builtin == "cap" && x.Cap >= 0 {
+			n = x.Cap
+		}
+	case x.isString() && builtin == "len":
+		if n, err = x.stringLen(); err != nil {
+			return nil, err
+		}
+	case x.isMap() && builtin == "len":
+		if n, err = x.mapLen(); err != nil {
+			return nil, err
+		}
+	case x.isChan():
+		st, err := x.chanState()
+		if err != nil {
+			return nil, err
+		}
+		if st != nil {
+			n = st.len
+			if builtin == "cap" {
+				n = st.cap
+			}
+		}
+	default:
+		return nil, fmt.Errorf("invalid argument %s (type %s) for %s", exprToString(node.Args[0]), x.Type, builtin)
+	}
+	return scope.newConstant(exprToString(node), constant.MakeInt64(n), intType), nil
+}
+
+// Evaluates expr to an integer, like an index.
+func (scope *EvalScope) evalInt(expr ast.Expr) (int64, error) {
+	c, err := scope.evalConstant(expr)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := constant.Int64Val(constant.ToInt(c))
+	if !ok {
+		return 0, fmt.Errorf("%s is not an integer", exprToString(expr))
+	}
+	return n, nil
+}
+
+// Returns the type expr refers to, like int, main.T or *main.T.
+// Unqualified names are also looked up in the current package.
+func (scope *EvalScope) findTypeExpr(expr ast.Expr) (dwarf.Type, error) {
+	switch node := expr.(type) {
+	case *ast.ParenExpr:
+		return scope.findTypeExpr(node.X)
+	case *ast.StarExpr:
+		// Pointer types are only in the debug info if the program uses them.
+		t, err := scope.findTypeExpr(node.X)
+		if err != nil {
+			return nil, err
+		}
+		return &dwarf.PtrType{CommonType: dwarf.CommonType{ByteSize: int64(scope.PtrSize())}, Type: t}, nil
+	}
+
+	dbp := scope.Thread.dbp
+	name := exprToString(expr)
+	t, err := dbp.findType(name)
+	if _, isident := expr.(*ast.Ident); err != nil && isident {
+		if pkg := scope.packageName(); pkg != "" {
+			t, err = dbp.findType(pkg + "." + name)
+		}
+	}
+	return t, err
+}
+
+// Returns the name of the package of the function scope is in.
+func (scope *EvalScope) packageName() string {
+	_, _, fn := scope.Thread.dbp.PCToLine(scope.PC)
+	if fn == nil {
+		return ""
+	}
+	return fn.PackageName()
+}
+
+// Returns expr with the package variable it starts with qualified by its
+// package, like main.v.f for v.f, so that it can be evaluated in any
+// scope. Returns an empty string if expr does not start with a package
+// variable.
+func (scope *EvalScope) packageExpr(expr string) string {
+	node, err := parser.ParseExpr(expr)
+	if err != nil {
+		return ""
+	}
+	var sel *ast.SelectorExpr
+	for {
+		switch n := node.(type) {
+		case *ast.SelectorExpr:
+			sel = n
+			node = n.X
+			continue
+		case *ast.IndexExpr:
+			node = n.X
+			continue
+		case *ast.SliceExpr:
+			node = n.X
+			continue
+		case *ast.StarExpr:
+			node = n.X
+			continue
+		case *ast.ParenExpr:
+			node = n.X
+			continue
+		}
+		break
+	}
+	ident, ok := node.(*ast.Ident)
+	if !ok {
+		return ""
+	}
+	if _, err := scope.extractVarInfo(ident.Name); err == nil {
+		// A local variable.
+		return ""
+	}
+	if sel != nil && sel.X == node {
+		if _, err := scope.packageVarAddr(ident.Name + "." + sel.Sel.Name); err == nil {
+			// Already qualified.
+			return expr
+		}
+	}
+	pkg := scope.packageName()
+	if pkg == "" {
+		return ""
+	}
+	if _, err := scope.packageVarAddr(pkg + "." + ident.Name); err != nil {
+		return ""
+	}
+	off := int(ident.Pos()) - 1
+	return expr[:off] + pkg + "." + expr[off:]
+}
+
+// Converts c to a value of type typ, integers wrap around as they do in
+// the target process. Untyped values, with a nil typ, are left alone.
+func convertConstant(c constant.Value, typ dwarf.Type) (constant.Value, error) {
+	switch t := resolveTypedef(typ).(type) {
+	case nil:
+		return c, nil
+	case *dwarf.IntType:
+		if n, ok := constantBits(c); ok {
+			shift := 64 - uint(t.ByteSize*8)
+			return constant.MakeInt64(int64(n<<shift) >> shift), nil
+		}
+	case *dwarf.UintType:
+		if n, ok := constantBits(c); ok {
+			if t.ByteSize < 8 {
+				n &= 1<<uint(t.ByteSize*8) - 1
+			}
+			return constant.MakeUint64(n), nil
+		}
+	case *dwarf.PtrType:
+		if n, ok := constantBits(c); ok {
+			return constant.MakeUint64(n), nil
+		}
+	case *dwarf.FloatType:
+		if c.Kind() == constant.Int || c.Kind() == constant.Float {
+			f, _ := constant.Float64Val(constant.ToFloat(c))
+			if t.ByteSize == 4 {
+				f = float64(float32(f))
+			}
+			return constant.MakeFloat64(f), nil
+		}
+	case *dwarf.BoolType:
+		if c.Kind() == constant.Bool {
+			return c, nil
+		}
+	case *dwarf.StructType:
+		if t.StructName == "string" && c.Kind() == constant.String {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("can not convert %s to %s", c, typ)
+}
+
+// Returns the low 64 bits of the integer part of c.
+func constantBits(c constant.Value) (uint64, bool) {
+	switch c.Kind() {
+	case constant.Float:
+		f, _ := constant.Float64Val(c)
+		c = constant.ToInt(constant.MakeFloat64(math.Trunc(f)))
+	case constant.Int:
+	default:
+		return 0, false
+	}
+	return constant.Uint64Val(constant.BinaryOp(c, token.AND, constant.MakeUint64(math.MaxUint64)))
+}
+
+func isNumericConstant(v constant.Value) bool {
+	switch v.Kind() {
+	case constant.Int, constant.Float, constant.Complex:
+		return true
+	}
+	return false
+}
+
+func compatibleConstants(x, y constant.Value) bool {
+	if isNumericConstant(x) && isNumericConstant(y) {
+		return true
+	}
+	return x.Kind() == y.Kind()
+}
+
+// Returns the value of a variable of basic type as a constant.
+func (v *Variable) constantValue() (constant.Value, error) {
+	if v.value != nil {
+		return v.value, nil
+	}
+	v = v.resolveTypedefs()
+
+	switch t := v.dwarfType.(type) {
+	case *dwarf.IntType:
+		n, err := v.thread.readIntRaw(v.Addr, t.ByteSize)
+		if err != nil {
+			return nil, err
+		}
+		switch t.ByteSize {
+		case 1:
+			n = int64(int8(n))
+		case 2:
+			n = int64(int16(n))
+		case 4:
+			n = int64(int32(n))
+		}
+		return constant.MakeInt64(n), nil
+	case *dwarf.UintType:
+		n, err := v.thread.readUintRaw(v.Addr, t.ByteSize)
+		if err != nil {
+			return nil, err
+		}
+		return constant.MakeUint64(n), nil
+	case *dwarf.PtrType:
+		n, err := v.thread.readUintRaw(v.Addr, int64(v.thread.dbp.arch.PtrSize()))
+		if err != nil {
+			return nil, err
+		}
+		return constant.MakeUint64(n), nil
+	case *dwarf.FloatType:
+		s, err := v.readFloat(t.ByteSize)
+		if err != nil {
+			return nil, err
+		}
+		f, err := strconv.ParseFloat(s, int(t.ByteSize*8))
+		if err != nil {
+			return nil, err
+		}
+		return constant.MakeFloat64(f), nil
+	case *dwarf.BoolType:
+		s, err := v.readBool()
+		if err != nil {
+			return nil, err
+		}
+		return constant.MakeBool(s == "true"), nil
+	case *dwarf.StructType:
+		if t.StructName == "string" {
+			n, err := v.stringLen()
+			if err != nil {
+				return nil, err
+			}
+			s, err := v.stringSlice(0, n)
+			if err != nil {
+				return nil, err
+			}
+			return constant.MakeString(s), nil
+		}
+	}
+
+	return nil, fmt.Errorf("can not use %s of type %s in an expression", v.Name, v.dwarfType)
+}
+
+func exprToString(t ast.Expr) string {
+	var buf bytes.Buffer
+	printer.Fprint(&buf, token.NewFileSet(), t)
+	return buf.String()
+}
@@ -0,0 +1,350 @@
+package proc
+
+import (
+	"debug/dwarf"
+	"debug/gosym"
+	"encoding/binary"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/parser"
+	"math"
+	"strings"
+)
+
+// Call calls a function of the target process on the goroutine running
+// on the current thread and returns its return values. expr must be a
+// call expression, like "main.add(1, x)", unqualified function names
+// are looked up in the package of the current function. Methods are
+// called on a variable, like "obj.String()", and looked up from its type.
+//
+// Arguments are either constants, which are converted to the type of
+// the corresponding parameter, or variables of the same type as the
+// parameter, which are copied. Breakpoints and watchpoints are ignored
+// while the function runs, the call fails if the process stops before
+// the function returns at a syscall catchpoint or because of a signal.
+// The call is not visible to the runtime, functions that grow the stack
+// or trigger a garbage collection may crash the process.
+func (dbp *Process) Call(expr string) ([]*Variable, error) {
+	var rets []*Variable
+	err := dbp.run(func() (err error) {
+		rets, err = dbp.call(expr)
+		return err
+	})
+	return rets, err
+}
+
+func (dbp *Process) call(expr string) (rets []*Variable, err error) {
+	call, ok := parseCallExpr(expr)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a function call", expr)
+	}
+
+	thread := dbp.CurrentThread
+	if thread.blocked() {
+		return nil, fmt.Errorf("can not call functions while the thread is blocked in a system call")
+	}
+	scope, err := thread.Scope()
+	if err != nil {
+		return nil, err
+	}
+	curfn := dbp.goSymTable.PCToFunc(scope.PC)
+	if curfn == nil {
+		return nil, fmt.Errorf("could not find function at %#x", scope.PC)
+	}
+	if strings.HasPrefix(curfn.Name, "runtime.") || strings.HasPrefix(curfn.Name, "syscall.") {
+		return nil, fmt.Errorf("can not call functions while the goroutine is in %s", curfn.Name)
+	}
+	g, err := thread.GetG()
+	if err != nil {
+		return nil, err
+	}
+	if g == nil {
+		return nil, fmt.Errorf("no goroutine running on thread %d", thread.Id)
+	}
+
+	fn, recv, err := scope.callee(call.Fun, curfn)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parameter offsets are relative to the CFA of the called function.
+	args, rets, err := dbp.functionParameters(thread, fn.Entry, 0)
+	if err != nil {
+		return nil, err
+	}
+	// The receiver of a method is its first parameter.
+	nrecv := 0
+	if recv != nil {
+		nrecv = 1
+	}
+	if len(call.Args)+nrecv != len(args) {
+		return nil, fmt.Errorf("wrong number of arguments to %s, expected %d", fn.Name, len(args)-nrecv)
+	}
+	var argsize int64
+	for _, v := range append(args, rets...) {
+		if end := int64(v.Addr) + v.dwarfType.Size(); end > argsize {
+			argsize = end
+		}
+	}
+	argsize = (argsize + 7) &^ 7
+
+	// Read all arguments before touching the stack of the goroutine.
+	argData := make([][]byte, len(args))
+	if recv != nil {
+		if int64(len(recv)) != args[0].dwarfType.Size() {
+			return nil, fmt.Errorf("can not use %s as the receiver of %s", exprToString(call.Fun.(*ast.SelectorExpr).X), fn.Name)
+		}
+		argData[0] = recv
+	}
+	for i := nrecv; i < len(args); i++ {
+		if argData[i], err = scope.argumentBytes(call.Args[i-nrecv], args[i]); err != nil {
+			return nil, fmt.Errorf("argument %s: %s", args[i].Name, err)
+		}
+	}
+
+	frames, err := thread.Stacktrace(0)
+	if err != nil {
+		return nil, err
+	}
+	regs, err := thread.saveRegisters()
+	if err != nil {
+		return nil, err
+	}
+	origPC, origSP := regs.PC(), regs.SP()
+
+	// The arguments and the results go right above the return address,
+	// as if the current function had called fn.
+	entrySP := origSP - uint64(argsize) - uint64(dbp.arch.PtrSize())
+	cfa := int64(entrySP) + int64(dbp.arch.PtrSize())
+	retaddr := make([]byte, dbp.arch.PtrSize())
+	binary.LittleEndian.PutUint64(retaddr, origPC)
+	if _, err := thread.writeMemory(uintptr(entrySP), retaddr); err != nil {
+		return nil, err
+	}
+	for i := range args {
+		if _, err := thread.writeMemory(uintptr(cfa)+args[i].Addr, argData[i]); err != nil {
+			return nil, err
+		}
+	}
+	if err := thread.setCallRegisters(fn.Entry, entrySP); err != nil {
+		return nil, err
+	}
+	// Whether the call returned or not, the goroutine goes back to where
+	// it was.
+	defer func() {
+		if rerr := dbp.restoreGoroutineRegisters(g, thread); err == nil {
+			err = rerr
+		}
+		if err != nil {
+			rets = nil
+		}
+	}()
+
+	// Once fn returns the goroutine is back at origPC, with its stack
+	// pointer above the arguments.
+	retCFA := frames[0].CFA - (int64(origSP) - cfa)
+	reached, err := dbp.continueGoroutineTo(g, origPC, retCFA, false)
+	if err != nil {
+		return nil, err
+	}
+	if !reached {
+		// The goroutine is moved back, tell where the process stopped
+		// before that.
+		loc, err := dbp.CurrentThread.Location()
+		if err != nil {
+			return nil, fmt.Errorf("the call of %s was interrupted, the process stopped before it returned", fn.Name)
+		}
+		return nil, fmt.Errorf("the call of %s was interrupted, thread %d stopped at %s:%d before it returned", fn.Name, dbp.CurrentThread.Id, loc.File, loc.Line)
+	}
+
+	_, rets, err = dbp.functionParameters(dbp.CurrentThread, fn.Entry, cfa)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range rets {
+		if lerr := v.loadValue(true); lerr != nil {
+			v.Value = fmt.Sprintf("(unreadable %s)", lerr)
+		}
+	}
+	return rets, nil
+}
+
+// Restores the registers saved on orig before a function call on
+// goroutine g, on the thread now running g.
+func (dbp *Process) restoreGoroutineRegisters(g *G, orig *Thread) error {
+	for _, th := range dbp.Threads {
+		if thg, err := th.GetG(); err == nil && thg != nil && thg.Id == g.Id {
+			return th.restoreCallRegisters(orig)
+		}
+	}
+	return fmt.Errorf("could not restore goroutine %d after the call, it is not running on any thread", g.Id)
+}
+
+// Returns the function called by fun. Functions are looked up by name,
+// unqualified names in the package of curfn. Otherwise fun must select a
+// method of a variable, the method is looked up from the type of the
+// variable and the bytes of the receiver to pass to it are returned too.
+func (scope *EvalScope) callee(fun ast.Expr, curfn *gosym.Func) (*gosym.Func, []byte, error) {
+	symbols := scope.Thread.dbp.goSymTable
+	name := exprToString(fun)
+	if fn := symbols.LookupFunc(name); fn != nil {
+		return fn, nil, nil
+	}
+	if !strings.Contains(name, ".") {
+		if fn := symbols.LookupFunc(curfn.PackageName() + "." + name); fn != nil {
+			return fn, nil, nil
+		}
+	}
+	sel, ok := fun.(*ast.SelectorExpr)
+	if !ok {
+		return nil, nil, fmt.Errorf("could not find function %s", name)
+	}
+	v, err := scope.evalAST(sel.X)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not find function %s", name)
+	}
+	if !v.addressable() {
+		return nil, nil, fmt.Errorf("can not call method %s of %s, it is not a variable", sel.Sel.Name, exprToString(sel.X))
+	}
+
+	// The method is either main.T.M or main.(*T).M, whether the variable
+	// is a T or a *T.
+	typ := v.dwarfType
+	pt, isptr := resolveTypedef(typ).(*dwarf.PtrType)
+	if isptr {
+		typ = pt.Type
+	}
+	tname := dwarfTypeName(typ)
+	dot := strings.LastIndex(tname, ".")
+	if dot < 0 || dot < strings.LastIndex(tname, "/") {
+		return nil, nil, fmt.Errorf("could not find method %s of type %s", sel.Sel.Name, tname)
+	}
+	pkg, tname := tname[:dot], tname[dot+1:]
+	valueMethod := symbols.LookupFunc(pkg + "." + tname + "." + sel.Sel.Name)
+	ptrMethod := symbols.LookupFunc(pkg + ".(*" + tname + ")." + sel.Sel.Name)
+
+	ptrSize := scope.Thread.dbp.arch.PtrSize()
+	switch {
+	case isptr && ptrMethod != nil:
+		recv, err := scope.Thread.readMemory(v.Addr, ptrSize)
+		return ptrMethod, recv, err
+	case isptr && valueMethod != nil:
+		val, err := v.maybeDereference()
+		if err != nil {
+			return nil, nil, err
+		}
+		recv, err := scope.Thread.readMemory(val.Addr, int(typ.Size()))
+		return valueMethod, recv, err
+	case valueMethod != nil:
+		recv, err := scope.Thread.readMemory(v.Addr, int(typ.Size()))
+		return valueMethod, recv, err
+	case ptrMethod != nil:
+		recv := make([]byte, ptrSize)
+		binary.LittleEndian.PutUint64(recv, uint64(v.Addr))
+		return ptrMethod, recv, nil
+	}
+	return nil, nil, fmt.Errorf("could not find method %s of type %s", sel.Sel.Name, dwarfTypeName(v.dwarfType))
+}
+
+func parseCallExpr(expr string) (*ast.CallExpr, bool) {
+	t, err := parser.ParseExpr(expr)
+	if err != nil {
+		return nil, false
+	}
+	call, ok := t.(*ast.CallExpr)
+	return call, ok
+}
+
+// Returns the formal parameters of the function starting at entry,
+// split in arguments and return values, for the frame with the given
+// CFA.
+func (dbp *Process) functionParameters(thread *Thread, entry uint64, cfa int64) (args, rets []*Variable, err error) {
+	reader := dbp.DwarfReader()
+	if _, err := reader.SeekToFunction(entry); err != nil {
+		return nil, nil, err
+	}
+	scope := &EvalScope{Thread: thread, PC: entry, CFA: cfa}
+	for entry, err := reader.NextScopeVariable(); entry != nil; entry, err = reader.NextScopeVariable() {
+		if err != nil {
+			return nil, nil, err
+		}
+		if entry.Tag != dwarf.TagFormalParameter {
+			continue
+		}
+		v, err := scope.extractVarInfoFromEntry(entry, reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		if isret, _ := entry.Val(dwarf.AttrVarParam).(bool); isret {
+			rets = append(rets, v)
+		} else {
+			args = append(args, v)
+		}
+	}
+	return args, rets, nil
+}
+
+// Returns the bytes to write in the stack slot of param for the
+// argument expr.
+func (scope *EvalScope) argumentBytes(expr ast.Expr, param *Variable) ([]byte, error) {
+	typ := param.resolveTypedefs().dwarfType
+	switch typ.(type) {
+	case *dwarf.IntType, *dwarf.UintType, *dwarf.FloatType, *dwarf.BoolType, *dwarf.PtrType:
+		c, err := scope.evalConstant(expr)
+		if err != nil {
+			return nil, err
+		}
+		return constantBytes(c, typ)
+	}
+
+	// Anything else must be a variable of the same type.
+	v, err := scope.evalAST(expr)
+	if err != nil {
+		return nil, err
+	}
+	if !v.addressable() {
+		return nil, fmt.Errorf("can not use %s (type %s) as type %s", exprToString(expr), v.Type, param.dwarfType)
+	}
+	if v.dwarfType.String() != param.dwarfType.String() {
+		return nil, fmt.Errorf("can not use %s (type %s) as type %s", exprToString(expr), v.dwarfType, param.dwarfType)
+	}
+	return scope.Thread.readMemory(v.Addr, int(typ.Size()))
+}
+
+// Encodes the constant c as a value of type typ.
+func constantBytes(c constant.Value, typ dwarf.Type) ([]byte, error) {
+	buf := make([]byte, 8)
+	switch typ.(type) {
+	case *dwarf.IntType:
+		n, exact := constant.Int64Val(constant.ToInt(c))
+		if !exact {
+			return nil, fmt.Errorf("can not use %s as %s", c, typ)
+		}
+		binary.LittleEndian.PutUint64(buf, uint64(n))
+	case *dwarf.UintType, *dwarf.PtrType:
+		n, exact := constant.Uint64Val(constant.ToInt(c))
+		if !exact {
+			return nil, fmt.Errorf("can not use %s as %s", c, typ)
+		}
+		binary.LittleEndian.PutUint64(buf, n)
+	case *dwarf.FloatType:
+		f, _ := constant.Float64Val(constant.ToFloat(c))
+		if c.Kind() != constant.Int && c.Kind() != constant.Float {
+			return nil, fmt.Errorf("can not use %s as %s", c, typ)
+		}
+		if typ.Size() == 4 {
+			binary.LittleEndian.PutUint32(buf, math.Float32bits(float32(f)))
+		} else {
+			binary.LittleEndian.PutUint64(buf, math.Float64bits(f))
+		}
+	case *dwarf.BoolType:
+		if c.Kind() != constant.Bool {
+			return nil, fmt.Errorf("can not use %s as %s", c, typ)
+		}
+		if constant.BoolVal(c) {
+			buf[0] = 1
+		}
+	}
+	return buf[:typ.Size()], nil
+}
@@ -0,0 +1,11 @@
+package proc
+
+import "fmt"
+
+func (thread *Thread) setCallRegisters(pc, sp uint64) error {
+	return fmt.Errorf("function calls are only supported on linux/amd64")
+}
+
+func (thread *Thread) restoreCallRegisters(orig *Thread) error {
+	return fmt.Errorf("function calls are only supported on linux/amd64")
+}
@@ -0,0 +1,28 @@
+package proc
+
+import sys "golang.org/x/sys/unix"
+
+// Sets the registers saved by saveRegisters on thread, with the stack
+// pointer at sp and the program counter at pc, to start a function call.
+func (thread *Thread) setCallRegisters(pc, sp uint64) (err error) {
+	regs := thread.os.registers
+	regs.Rsp = sp
+	regs.SetPC(pc)
+	thread.dbp.execPtraceFunc(func() { err = sys.PtraceSetRegs(thread.Id, &regs) })
+	return
+}
+
+// Restores on thread the registers saved on orig before a function
+// call. The goroutine may have moved to a different thread during the
+// call, the thread local storage of thread is kept.
+func (thread *Thread) restoreCallRegisters(orig *Thread) (err error) {
+	var cur sys.PtraceRegs
+	thread.dbp.execPtraceFunc(func() { err = sys.PtraceGetRegs(thread.Id, &cur) })
+	if err != nil {
+		return
+	}
+	regs := orig.os.registers
+	regs.Fs_base = cur.Fs_base
+	thread.dbp.execPtraceFunc(func() { err = sys.PtraceSetRegs(thread.Id, &regs) })
+	return
+}
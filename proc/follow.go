@@ -0,0 +1,41 @@
+package proc
+
+import "fmt"
+
+// FollowMode chooses which processes are debugged after the process
+// forks.
+type FollowMode string
+
+const (
+	// FollowParent keeps debugging the parent, children are not traced.
+	FollowParent FollowMode = "parent"
+	// FollowChild debugs the child, the parent is detached and runs
+	// freely.
+	FollowChild FollowMode = "child"
+	// FollowBoth debugs both processes, the parent stops after forking
+	// and the child is held stopped until it is resumed.
+	FollowBoth FollowMode = "both"
+)
+
+// SetFollowMode sets which processes are debugged after the process
+// forks. When following children the process also stops after
+// executing a new program, since its breakpoints do not apply anymore.
+func (dbp *Process) SetFollowMode(mode FollowMode) error {
+	switch mode {
+	case FollowParent, FollowChild, FollowBoth:
+	default:
+		return fmt.Errorf("unknown follow mode %q, must be parent, child or both", mode)
+	}
+	dbp.followMode = mode
+	return dbp.setPtraceOptions()
+}
+
+// FollowMode returns which processes are debugged after the process
+// forks.
+func (dbp *Process) FollowMode() FollowMode {
+	return dbp.followMode
+}
+
+func (dbp *Process) followChildren() bool {
+	return dbp.followMode == FollowChild || dbp.followMode == FollowBoth
+}
@@ -0,0 +1,10 @@
+package proc
+
+import "fmt"
+
+func (dbp *Process) setPtraceOptions() error {
+	if dbp.followChildren() {
+		return fmt.Errorf("following child processes is only supported on linux")
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+package proc
+
+import (
+	"debug/elf"
+	"fmt"
+	"os"
+	"syscall"
+
+	sys "golang.org/x/sys/unix"
+)
+
+// Returns the ptrace options set on every traced thread.
+func (dbp *Process) ptraceOptions() int {
+	opts := syscall.PTRACE_O_TRACECLONE | sys.PTRACE_O_TRACESYSGOOD
+	if dbp.followChildren() {
+		opts |= sys.PTRACE_O_TRACEFORK | sys.PTRACE_O_TRACEVFORK | sys.PTRACE_O_TRACEEXEC
+	}
+	return opts
+}
+
+func (dbp *Process) setPtraceOptions() error {
+	for _, th := range dbp.Threads {
+		var err error
+		dbp.execPtraceFunc(func() { err = syscall.PtraceSetOptions(th.Id, dbp.ptraceOptions()) })
+		if err != nil {
+			return fmt.Errorf("could not set options for thread %d %s", th.Id, err)
+		}
+	}
+	return nil
+}
+
+// Handles the fork event of thread, returns true if the process should
+// stop. Otherwise the thread, or the child when following it, has been
+// resumed.
+func (dbp *Process) handleFork(thread *Thread, vfork bool) (bool, error) {
+	var (
+		msg uint
+		err error
+	)
+	dbp.execPtraceFunc(func() { msg, err = sys.PtraceGetEventMsg(thread.Id) })
+	if err != nil {
+		return false, fmt.Errorf("could not get event message: %s", err)
+	}
+	pid := int(msg)
+
+	switch dbp.followMode {
+	case FollowChild:
+		if err := dbp.followChild(pid, vfork); err != nil {
+			return false, err
+		}
+		return false, dbp.CurrentThread.Continue()
+	case FollowBoth:
+		child, err := dbp.newChild(pid, vfork)
+		if err != nil {
+			return false, err
+		}
+		dbp.Forked = append(dbp.Forked, child)
+		return true, nil
+	}
+	// The options were changed after the fork started.
+	if err := dbp.detachChild(pid); err != nil {
+		return false, err
+	}
+	return false, thread.Continue()
+}
+
+// Detaches from the process and starts debugging its child pid in its
+// place. After a vfork the memory of the child is the memory of the
+// parent, removing the breakpoints from the parent removes them from
+// the child as well, so they are deleted.
+func (dbp *Process) followChild(pid int, vfork bool) error {
+	// Threads can only be detached while stopped.
+	if err := dbp.Halt(); err != nil {
+		return err
+	}
+	for addr, bp := range dbp.Breakpoints {
+		if bp.active() {
+			if _, err := bp.Clear(dbp.CurrentThread); err != nil {
+				return err
+			}
+		}
+		if vfork {
+			delete(dbp.Breakpoints, addr)
+		}
+	}
+	for _, th := range dbp.Threads {
+		for _, wp := range dbp.Watchpoints {
+			if wp.Software {
+				continue
+			}
+			if err := th.clearWatchpoint(wp); err != nil {
+				return err
+			}
+		}
+		var err error
+		dbp.execPtraceFunc(func() { err = PtraceDetach(th.Id, 0) })
+		if err != nil && err != sys.ESRCH {
+			return fmt.Errorf("could not detach thread %d %s", th.Id, err)
+		}
+	}
+	return dbp.switchProcess(pid)
+}
+
+// Returns a Process for the child pid, forked by dbp, which is traced
+// and stopped. The child shares the debug information and the ptrace
+// thread of the parent. The breakpoints are copied, since the memory of
+// the child is a copy of the memory of the parent.
+func (dbp *Process) newChild(pid int, vfork bool) (*Process, error) {
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return nil, err
+	}
+	// The child has its own debug registers, they are not inherited.
+	arch := AMD64Arch()
+	arch.gStructOffset = dbp.arch.GStructOffset()
+	child := &Process{
+		Pid:                     pid,
+		Process:                 proc,
+		Threads:                 make(map[int]*Thread),
+		Breakpoints:             make(map[uint64]*Breakpoint),
+		Watchpoints:             make(map[int]*Watchpoint),
+		SyscallCatchpoints:      make(map[int]*SyscallCatchpoint),
+		dwarf:                   dbp.dwarf,
+		goSymTable:              dbp.goSymTable,
+		frameEntries:            dbp.frameEntries,
+		lineInfo:                dbp.lineInfo,
+		os:                      new(OSProcessDetails),
+		arch:                    arch,
+		ast:                     dbp.ast,
+		breakpointIDCounter:     dbp.breakpointIDCounter,
+		tempBreakpointIDCounter: dbp.tempBreakpointIDCounter,
+		followMode:              dbp.followMode,
+		maxMapEntries:           dbp.maxMapEntries,
+		ptraceChan:              dbp.ptraceChan,
+		ptraceDoneChan:          dbp.ptraceDoneChan,
+	}
+	if _, err := child.addThread(pid, false); err != nil {
+		return nil, err
+	}
+	for addr, bp := range dbp.Breakpoints {
+		if bp.Temp {
+			// Temporary breakpoints belong to the command the parent
+			// is running, after a vfork the parent removes them itself.
+			if bp.Enabled && !vfork {
+				if _, err := bp.Clear(child.CurrentThread); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		if bp.temp != nil && !bp.Enabled && !vfork {
+			if _, err := bp.Clear(child.CurrentThread); err != nil {
+				return nil, err
+			}
+		}
+		nbp := *bp
+		nbp.temp = nil
+		child.Breakpoints[addr] = &nbp
+	}
+	for id, cp := range dbp.SyscallCatchpoints {
+		child.SyscallCatchpoints[id] = cp
+	}
+	for sig, p := range dbp.signalPolicies {
+		child.SetSignalPolicy(sig, p)
+	}
+	return child, nil
+}
+
+// Lets the child pid run without being traced.
+func (dbp *Process) detachChild(pid int) error {
+	if _, _, err := wait(pid, pid, 0); err != nil {
+		return err
+	}
+	var err error
+	dbp.execPtraceFunc(func() { err = PtraceDetach(pid, 0) })
+	return err
+}
+
+// Handles the exec event of the process, the debug information is
+// loaded again from the new program and the breakpoints and watchpoints,
+// which belonged to the old one, are deleted. If the new program can not be debugged
+// the process is detached.
+func (dbp *Process) handleExec() error {
+	path, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", dbp.Pid))
+	if err != nil {
+		return err
+	}
+	// Only the thread group leader survives an exec.
+	leader, ok := dbp.Threads[dbp.Pid]
+	if !ok {
+		return fmt.Errorf("could not find thread %d after exec", dbp.Pid)
+	}
+	dbp.Threads = map[int]*Thread{dbp.Pid: leader}
+	dbp.CurrentThread = leader
+	dbp.SelectedGoroutine = nil
+	dbp.allGCache = nil
+	// The panic breakpoints are set again in the new program, unless
+	// they were cleared.
+	panicBreakpoints := false
+	for _, bp := range dbp.Breakpoints {
+		if bp.Internal() {
+			panicBreakpoints = true
+		}
+	}
+	dbp.Breakpoints = make(map[uint64]*Breakpoint)
+	// Releases the debug registers of hardware watchpoints.
+	for id := range dbp.Watchpoints {
+		if _, err := dbp.ClearWatchpoint(id); err != nil {
+			return err
+		}
+	}
+	dbp.ExecPath = path
+
+	if err := checkDebugInfo(path); err != nil {
+		dbp.execPtraceFunc(func() { PtraceDetach(dbp.Pid, 0) })
+		dbp.exited = true
+		return fmt.Errorf("process %d executed %s, which can not be debugged: %s", dbp.Pid, path, err)
+	}
+	if err := dbp.LoadInformation(path); err != nil {
+		return err
+	}
+	ver, isextld, err := dbp.getGoInformation()
+	if err != nil {
+		return err
+	}
+	dbp.arch.SetGStructOffset(ver, isextld)
+	dbp.SelectedGoroutine, _ = leader.GetG()
+	if !panicBreakpoints {
+		return nil
+	}
+	return dbp.SetPanicBreakpoints()
+}
+
+// Returns an error if the program at path does not have the sections
+// LoadInformation needs.
+func checkDebugInfo(path string) error {
+	exe, err := elf.Open(path)
+	if err != nil {
+		return err
+	}
+	defer exe.Close()
+	for _, name := range []string{".debug_frame", ".debug_line", ".gopclntab"} {
+		if exe.Section(name) == nil {
+			return fmt.Errorf("no %s section", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,322 @@
+package proc
+
+import (
+	"debug/dwarf"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"strings"
+)
+
+// Number of entries of a map loaded when printing it, unless changed
+// with SetMaxMapEntries.
+const defaultMaxMapEntries = 64
+
+// Smaller tophash values in the buckets of a map mark empty cells and
+// cells evacuated to the new buckets while the map grows, see
+// runtime/hashmap.go.
+const hashMinTopHash = 4
+
+// SetMaxMapEntries sets the number of entries of a map loaded when
+// printing it, the remaining ones are only counted.
+func (dbp *Process) SetMaxMapEntries(n int) error {
+	if n <= 0 {
+		return fmt.Errorf("invalid number of map entries %d", n)
+	}
+	dbp.maxMapEntries = n
+	return nil
+}
+
+// MaxMapEntries returns the number of entries of a map loaded when
+// printing it.
+func (dbp *Process) MaxMapEntries() int {
+	return dbp.maxMapEntries
+}
+
+// Reports whether v is a map. Maps are pointers to a runtime hash map,
+// described in the debug info by a struct named hash<K,V>.
+func (v *Variable) isMap() bool {
+	t, ok := resolveTypedef(v.dwarfType).(*dwarf.PtrType)
+	if !ok {
+		return false
+	}
+	st, ok := resolveTypedef(t.Type).(*dwarf.StructType)
+	return ok && strings.HasPrefix(st.StructName, "hash<")
+}
+
+// Returns the runtime hash map of the map v, or nil if v is a nil map.
+func (v *Variable) mapHash() (*Variable, error) {
+	h, err := v.maybeDereference()
+	if err != nil {
+		return nil, err
+	}
+	if h.Addr == 0 {
+		return nil, nil
+	}
+	h.Name = v.Name
+	return h, nil
+}
+
+// Returns the number of entries of the map v.
+func (v *Variable) mapLen() (int64, error) {
+	h, err := v.mapHash()
+	if err != nil || h == nil {
+		return 0, err
+	}
+	count, err := h.structMember("count")
+	if err != nil {
+		return 0, fmt.Errorf("unsupported map layout: %s", err)
+	}
+	return v.thread.readIntRaw(count.Addr, count.dwarfType.Size())
+}
+
+// Calls fn on the entries of the map v until it returns false.
+//
+// While the map grows its entries are split between the old buckets
+// and the new ones. Entries of old buckets that were already evacuated
+// are marked as such and skipped, so walking both sets of buckets sees
+// every entry once.
+func (v *Variable) forEachMapEntry(fn func(key, value *Variable) bool) error {
+	h, err := v.mapHash()
+	if err != nil || h == nil {
+		return err
+	}
+	b, err := h.structMember("B")
+	if err != nil {
+		return fmt.Errorf("unsupported map layout: %s", err)
+	}
+	logsize, err := v.thread.readUintRaw(b.Addr, 1)
+	if err != nil {
+		return err
+	}
+	buckets, err := h.structMember("buckets")
+	if err != nil {
+		return fmt.Errorf("unsupported map layout: %s", err)
+	}
+	oldbuckets, err := h.structMember("oldbuckets")
+	if err != nil {
+		return fmt.Errorf("unsupported map layout: %s", err)
+	}
+
+	// Keys and values larger than 128 bytes are stored as pointers, in
+	// that case the type of the cells differs from the one of the map.
+	keyName, valueName := hashTypeArgs(h.resolveTypedefs().dwarfType.(*dwarf.StructType).StructName)
+	it := &bucketIterator{keyName: keyName, valueName: valueName, fn: fn}
+
+	nbuckets := uint64(1) << logsize
+	if err := it.walk(oldbuckets, nbuckets/2); err != nil || it.stopped {
+		return err
+	}
+	return it.walk(buckets, nbuckets)
+}
+
+// Walks the buckets of a map and their chains of overflow buckets.
+type bucketIterator struct {
+	keyName, valueName string
+	fn                 func(key, value *Variable) bool
+	stopped            bool
+}
+
+// Walks the array of n buckets bucketsv points to.
+func (it *bucketIterator) walk(bucketsv *Variable, n uint64) error {
+	t, ok := resolveTypedef(bucketsv.dwarfType).(*dwarf.PtrType)
+	if !ok {
+		return fmt.Errorf("unsupported map layout: buckets of type %s", bucketsv.dwarfType)
+	}
+	thread := bucketsv.thread
+	base, err := thread.readUintRaw(bucketsv.Addr, int64(thread.dbp.arch.PtrSize()))
+	if err != nil || base == 0 {
+		return err
+	}
+	size := uint64(t.Type.Size())
+
+	for i := uint64(0); i < n; i++ {
+		seen := make(map[uintptr]bool)
+		for addr := uintptr(base + i*size); addr != 0 && !seen[addr]; {
+			seen[addr] = true
+			b, err := newVariable("", addr, t.Type, thread)
+			if err != nil {
+				return err
+			}
+			if addr, err = it.walkBucket(b); err != nil || it.stopped {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Calls fn on the entries of bucket b and returns the address of its
+// overflow bucket.
+func (it *bucketIterator) walkBucket(b *Variable) (uintptr, error) {
+	var fields [4]*Variable
+	for i, name := range []string{"tophash", "keys", "values", "overflow"} {
+		f, err := b.structMember(name)
+		if err != nil {
+			return 0, fmt.Errorf("unsupported map layout: %s", err)
+		}
+		fields[i] = f
+	}
+	tophash, keys, values, overflow := fields[0], fields[1], fields[2], fields[3]
+	if !tophash.isArrayOrSlice() || !keys.isArrayOrSlice() || !values.isArrayOrSlice() {
+		return 0, fmt.Errorf("unsupported map layout: bucket of type %s", b.dwarfType)
+	}
+
+	thread := b.thread
+	for i := int64(0); i < tophash.Len; i++ {
+		top, err := thread.readUintRaw(tophash.base+uintptr(i*tophash.stride), 1)
+		if err != nil {
+			return 0, err
+		}
+		if top < hashMinTopHash {
+			continue
+		}
+		key, err := bucketCell(keys, i, it.keyName)
+		if err != nil {
+			return 0, err
+		}
+		value, err := bucketCell(values, i, it.valueName)
+		if err != nil {
+			return 0, err
+		}
+		if !it.fn(key, value) {
+			it.stopped = true
+			return 0, nil
+		}
+	}
+
+	next, err := thread.readUintRaw(overflow.Addr, int64(thread.dbp.arch.PtrSize()))
+	return uintptr(next), err
+}
+
+// Returns the i-th key or value of the cells of a bucket, following
+// the pointer of keys and values stored indirectly. typeName is the
+// name of the key or value type of the map.
+func bucketCell(cells *Variable, i int64, typeName string) (*Variable, error) {
+	v, err := newVariable("", uintptr(int64(cells.base)+i*cells.stride), cells.fieldType, cells.thread)
+	if err != nil {
+		return nil, err
+	}
+	if _, isptr := cells.fieldType.(*dwarf.PtrType); isptr && typeName != "" && dwarfTypeName(cells.fieldType) != typeName {
+		return v.maybeDereference()
+	}
+	return v, nil
+}
+
+// Returns the Go name of t, like *main.T. Only base types and typedefs
+// have a name in the dwarf package, the String of the others is in C
+// syntax.
+func dwarfTypeName(t dwarf.Type) string {
+	if name := t.Common().Name; name != "" {
+		return name
+	}
+	switch t := t.(type) {
+	case *dwarf.PtrType:
+		return "*" + dwarfTypeName(t.Type)
+	case *dwarf.StructType:
+		return t.StructName
+	case *dwarf.ArrayType:
+		return fmt.Sprintf("[%d]%s", t.Count, dwarfTypeName(t.Type))
+	}
+	return t.String()
+}
+
+// Returns the key and value type names of the name of a runtime hash
+// map type, "hash<K,V>", or empty strings if it can not be parsed.
+func hashTypeArgs(name string) (key, value string) {
+	if !strings.HasPrefix(name, "hash<") || !strings.HasSuffix(name, ">") {
+		return "", ""
+	}
+	args := name[len("hash<") : len(name)-1]
+	depth := 0
+	for i, c := range args {
+		switch c {
+		case '<', '[', '(', '{':
+			depth++
+		case '>', ']', ')', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				return args[:i], args[i+1:]
+			}
+		}
+	}
+	return "", ""
+}
+
+func (v *Variable) loadMap(recurseLevel int) (string, error) {
+	h, err := v.mapHash()
+	if err != nil {
+		return "", err
+	}
+	if h == nil {
+		return fmt.Sprintf("%s nil", v.Type), nil
+	}
+	count, err := v.mapLen()
+	if err != nil {
+		return "", err
+	}
+
+	max := v.thread.dbp.maxMapEntries
+	entries := make([]string, 0)
+	errcount := 0
+	load := func(v *Variable) string {
+		val, err := v.loadValueInternal(false, recurseLevel+1)
+		if err != nil {
+			errcount++
+			return fmt.Sprintf("<unreadable: %s>", err.Error())
+		}
+		return val
+	}
+	err = v.forEachMapEntry(func(key, value *Variable) bool {
+		if len(entries) >= max {
+			return false
+		}
+		entries = append(entries, fmt.Sprintf("%s: %s", load(key), load(value)))
+		return errcount <= maxErrCount
+	})
+	if err != nil {
+		return "", err
+	}
+	if more := count - int64(len(entries)); more > 0 {
+		entries = append(entries, fmt.Sprintf("...+%d more", more))
+	}
+	return fmt.Sprintf("%s len: %d, [%s]", v.Type, count, strings.Join(entries, ", ")), nil
+}
+
+// Evaluates the index expression node on the map m. Keys must be of a
+// basic type, like strings or integers.
+func (scope *EvalScope) evalMapIndex(node *ast.IndexExpr, m *Variable) (*Variable, error) {
+	kc, err := scope.evalConstant(node.Index)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		found  *Variable
+		keyErr error
+	)
+	err = m.forEachMapEntry(func(key, value *Variable) bool {
+		c, err := key.constantValue()
+		if err != nil {
+			keyErr = fmt.Errorf("can not index map with keys of type %s", key.Type)
+			return false
+		}
+		if compatibleConstants(c, kc) && constant.Compare(c, token.EQL, kc) {
+			found = value
+			return false
+		}
+		return true
+	})
+	if err == nil {
+		err = keyErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, fmt.Errorf("key %s not found in %s", exprToString(node.Index), exprToString(node.X))
+	}
+	found.Name = exprToString(node)
+	return found, nil
+}
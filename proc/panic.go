@@ -0,0 +1,93 @@
+package proc
+
+// Names of the breakpoints set by SetPanicBreakpoints.
+const (
+	UnrecoveredPanic = "unrecovered-panic"
+	FatalError       = "fatal-error"
+)
+
+// Runtime functions called when a panic is not recovered or a fatal
+// error is thrown, not all of them exist in every version of the
+// runtime. In older versions runtime.startpanic is used for both.
+var panicFunctions = []struct {
+	fn   string
+	name string
+}{
+	{"runtime.startpanic", UnrecoveredPanic},
+	{"runtime.fatalpanic", UnrecoveredPanic},
+	{"runtime.fatalthrow", FatalError},
+}
+
+// Max number of frames searched for runtime.gopanic or runtime.throw.
+const maxPanicFrames = 50
+
+// Sets breakpoints on the runtime functions handling unrecovered panics
+// and fatal errors, so that the process stops while the stack of the
+// panicking goroutine can still be inspected. These breakpoints have
+// negative IDs.
+func (dbp *Process) SetPanicBreakpoints() error {
+	id := 0
+	for _, pf := range panicFunctions {
+		addr, err := dbp.FindFunctionLocation(pf.fn, true, 0)
+		if err != nil {
+			// Source of the runtime not available.
+			addr, err = dbp.FindFunctionLocation(pf.fn, false, 0)
+		}
+		if err != nil {
+			continue
+		}
+		id--
+		bp, err := dbp.SetBreakpointWithID(id, addr)
+		if err != nil {
+			return err
+		}
+		bp.Name = pf.name
+	}
+	return nil
+}
+
+// Clears the breakpoints set by SetPanicBreakpoints.
+func (dbp *Process) ClearPanicBreakpoints() error {
+	for addr, bp := range dbp.Breakpoints {
+		if !bp.Internal() {
+			continue
+		}
+		if _, err := dbp.ClearBreakpoint(addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns the value passed to panic by the goroutine running on thread,
+// or the message of the fatal error thrown by the runtime. Returns nil
+// if there is no runtime.gopanic or runtime.throw frame on the stack.
+func (thread *Thread) PanicValue() (*Variable, error) {
+	frames, err := thread.Stacktrace(maxPanicFrames)
+	if err != nil {
+		return nil, err
+	}
+	for i := range frames {
+		if frames[i].Current.Fn == nil {
+			continue
+		}
+		var arg string
+		switch frames[i].Current.Fn.Name {
+		case "runtime.gopanic":
+			arg = "e"
+		case "runtime.throw":
+			arg = "s"
+		default:
+			continue
+		}
+		v, err := frames[i].Scope(thread).ExtractVariableInfo(arg)
+		if err != nil {
+			return nil, err
+		}
+		if err := v.loadValue(true); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+	return nil, nil
+}
@@ -10,6 +10,7 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"syscall"
 
 	sys "golang.org/x/sys/unix"
 
@@ -29,12 +30,30 @@ type Process struct {
 	// Maps instruction address to Breakpoint struct.
 	Breakpoints map[uint64]*Breakpoint
 
+	// Watchpoint table, maps watchpoint ID to Watchpoint struct.
+	Watchpoints map[int]*Watchpoint
+
+	// Syscall catchpoint table, maps catchpoint ID to SyscallCatchpoint struct.
+	SyscallCatchpoints map[int]*SyscallCatchpoint
+
 	// List of threads mapped as such: pid -> *Thread
 	Threads map[int]*Thread
 
 	// Active thread
 	CurrentThread *Thread
 
+	// Child processes forked by the process since it was last resumed,
+	// with FollowBoth. The children are stopped.
+	Forked []*Process
+
+	// Program executed by the process, when the process stopped because
+	// of an exec.
+	ExecPath string
+
+	// Signals received by the process since it was last resumed, whose
+	// policy is to print them or to stop.
+	Signals []ReceivedSignal
+
 	// Goroutine that will be used by default to set breakpoint, eval variables, etc...
 	// Normally SelectedGoroutine is CurrentThread.GetG, it will not be only if SwitchGoroutine is called with a goroutine that isn't attached to a thread
 	SelectedGoroutine *G
@@ -49,8 +68,14 @@ type Process struct {
 	arch                    Arch
 	ast                     *source.Searcher
 	breakpointIDCounter     int
+	checkpoints             []*Checkpoint
+	checkpointIDCounter     int
+	followMode              FollowMode
+	maxMapEntries           int
+	signalPolicies          map[syscall.Signal]SignalPolicy
 	tempBreakpointIDCounter int
 	halt                    bool
+	haltThread              *Thread
 	exited                  bool
 	ptraceChan              chan func()
 	ptraceDoneChan          chan interface{}
@@ -58,14 +83,18 @@ type Process struct {
 
 func New(pid int) *Process {
 	dbp := &Process{
-		Pid:            pid,
-		Threads:        make(map[int]*Thread),
-		Breakpoints:    make(map[uint64]*Breakpoint),
-		firstStart:     true,
-		os:             new(OSProcessDetails),
-		ast:            source.New(),
-		ptraceChan:     make(chan func()),
-		ptraceDoneChan: make(chan interface{}),
+		Pid:                pid,
+		Threads:            make(map[int]*Thread),
+		Breakpoints:        make(map[uint64]*Breakpoint),
+		Watchpoints:        make(map[int]*Watchpoint),
+		SyscallCatchpoints: make(map[int]*SyscallCatchpoint),
+		firstStart:         true,
+		followMode:         FollowParent,
+		maxMapEntries:      defaultMaxMapEntries,
+		os:                 new(OSProcessDetails),
+		ast:                source.New(),
+		ptraceChan:         make(chan func()),
+		ptraceDoneChan:     make(chan interface{}),
 	}
 	go dbp.handlePtraceFuncs()
 	return dbp
@@ -76,9 +105,13 @@ func New(pid int) *Process {
 type ProcessExitedError struct {
 	Pid    int
 	Status int
+	Signal syscall.Signal // Signal that killed the process, if any.
 }
 
 func (pe ProcessExitedError) Error() string {
+	if pe.Signal != 0 {
+		return fmt.Sprintf("Process %d was killed by signal %s", pe.Pid, SignalName(pe.Signal))
+	}
 	return fmt.Sprintf("Process %d has exited with status %d", pe.Pid, pe.Status)
 }
 
@@ -89,6 +122,9 @@ func (dbp *Process) Detach(kill bool) (err error) {
 			return
 		}
 	}
+	if err = dbp.clearCheckpoints(); err != nil {
+		return
+	}
 	if !kill {
 		// Clean up any breakpoints we've set.
 		for _, bp := range dbp.Breakpoints {
@@ -211,9 +247,53 @@ func (dbp *Process) SetBreakpoint(addr uint64) (*Breakpoint, error) {
 	return dbp.setBreakpoint(dbp.CurrentThread.Id, addr, false)
 }
 
+// Sets a breakpoint at addr with the specified ID, used to recreate
+// the breakpoints of a previous process.
+func (dbp *Process) SetBreakpointWithID(id int, addr uint64) (*Breakpoint, error) {
+	if _, ok := dbp.FindBreakpointByID(id); ok {
+		return nil, fmt.Errorf("breakpoint %d already exists", id)
+	}
+	counter := dbp.breakpointIDCounter
+	bp, err := dbp.setBreakpoint(dbp.CurrentThread.Id, addr, false)
+	if err != nil {
+		return nil, err
+	}
+	bp.ID = id
+	dbp.breakpointIDCounter = counter
+	if id > counter {
+		dbp.breakpointIDCounter = id
+	}
+	return bp, nil
+}
+
 // Sets a temp breakpoint, for the 'next' command.
+//
+// A temporary breakpoint can be set at the address of a user breakpoint,
+// even a disabled one, it is then kept along with the user breakpoint
+// instead of in the breakpoint table.
 func (dbp *Process) SetTempBreakpoint(addr uint64) (*Breakpoint, error) {
-	return dbp.setBreakpoint(dbp.CurrentThread.Id, addr, true)
+	bp, ok := dbp.Breakpoints[addr]
+	if !ok || bp.Temp || bp.temp != nil {
+		return dbp.setBreakpoint(dbp.CurrentThread.Id, addr, true)
+	}
+	if !bp.Enabled {
+		if err := dbp.writeSoftwareBreakpoint(dbp.CurrentThread, addr); err != nil {
+			return nil, err
+		}
+	}
+	dbp.tempBreakpointIDCounter++
+	bp.temp = &Breakpoint{
+		FunctionName: bp.FunctionName,
+		File:         bp.File,
+		Line:         bp.Line,
+		Addr:         addr,
+		OriginalData: bp.OriginalData,
+		ID:           dbp.tempBreakpointIDCounter,
+		Temp:         true,
+		Enabled:      true,
+		HitCount:     map[int]uint64{},
+	}
+	return bp.temp, nil
 }
 
 // Clears a breakpoint.
@@ -223,6 +303,13 @@ func (dbp *Process) ClearBreakpoint(addr uint64) (*Breakpoint, error) {
 		return nil, NoBreakpointError{addr: addr}
 	}
 
+	if bp.temp != nil {
+		// The temporary breakpoint keeps the instruction in memory.
+		dbp.Breakpoints[bp.Addr] = bp.temp
+		bp.temp = nil
+		return bp, nil
+	}
+
 	if _, err := bp.Clear(dbp.CurrentThread); err != nil {
 		return nil, err
 	}
@@ -232,6 +319,43 @@ func (dbp *Process) ClearBreakpoint(addr uint64) (*Breakpoint, error) {
 	return bp, nil
 }
 
+// Enables a previously disabled breakpoint, writing the breakpoint
+// instruction back to memory.
+func (dbp *Process) EnableBreakpoint(addr uint64) (*Breakpoint, error) {
+	bp, ok := dbp.FindBreakpoint(addr)
+	if !ok {
+		return nil, NoBreakpointError{addr: addr}
+	}
+	if bp.Enabled {
+		return bp, nil
+	}
+	if err := dbp.writeSoftwareBreakpoint(dbp.CurrentThread, bp.Addr); err != nil {
+		return nil, err
+	}
+	bp.Enabled = true
+	return bp, nil
+}
+
+// Disables a breakpoint, restoring the original instruction but
+// keeping the breakpoint, with its ID and attributes, in the
+// breakpoint table.
+func (dbp *Process) DisableBreakpoint(addr uint64) (*Breakpoint, error) {
+	bp, ok := dbp.FindBreakpoint(addr)
+	if !ok {
+		return nil, NoBreakpointError{addr: addr}
+	}
+	if !bp.Enabled {
+		return bp, nil
+	}
+	if bp.temp == nil {
+		if _, err := bp.Clear(dbp.CurrentThread); err != nil {
+			return nil, err
+		}
+	}
+	bp.Enabled = false
+	return bp, nil
+}
+
 // Returns the status of the current main thread context.
 func (dbp *Process) Status() *sys.WaitStatus {
 	return dbp.CurrentThread.Status
@@ -326,6 +450,382 @@ func (dbp *Process) next() (err error) {
 	}
 }
 
+// StepOut continues the current goroutine until the current function
+// returns, stopping at the instruction following its call. The values
+// returned by the function are stored in the ReturnValues of the thread.
+func (dbp *Process) StepOut() error {
+	return dbp.run(dbp.stepOut)
+}
+
+func (dbp *Process) stepOut() error {
+	frames, err := dbp.CurrentThread.Stacktrace(1)
+	if err != nil {
+		return err
+	}
+	// Scope of the function we are stepping out of, its return values
+	// are read from its frame after it returns.
+	fnScope := frames[0].Scope(dbp.CurrentThread)
+	if reached, err := dbp.runToReturn(frames); !reached || err != nil {
+		return err
+	}
+	fnScope.Thread = dbp.CurrentThread
+	dbp.CurrentThread.ReturnValues, _ = fnScope.ReturnValues()
+	return nil
+}
+
+// Resumes the process until the function of the topmost frame of
+// frames, the stack of the current thread, returns. Reports whether it
+// returned, see continueGoroutineTo.
+func (dbp *Process) runToReturn(frames []Stackframe) (bool, error) {
+	if len(frames) < 2 {
+		return false, NoReturnAddr{frames[0].Current.Fn.BaseName()}
+	}
+	if fn := frames[1].Current.Fn; fn != nil && fn.Name == "runtime.goexit" {
+		return false, fmt.Errorf("can not step out of %s, the goroutine would exit", frames[0].Current.Fn.Name)
+	}
+	g, err := dbp.CurrentThread.GetG()
+	if err != nil {
+		return false, err
+	}
+	return dbp.continueGoroutineTo(g, frames[1].Current.PC, frames[1].CFA, true)
+}
+
+// Resumes all threads until goroutine g reaches pc. If cfa is not zero g
+// must also be in the frame with that CFA, so that recursive calls
+// reaching pc are skipped. The thread running g becomes the current
+// thread.
+//
+// The process can also stop before, like Continue would, at a syscall
+// catchpoint, because of a signal and, unless breakpoints is false, at a
+// breakpoint or a watchpoint. The thread that stopped becomes the current
+// thread and false is returned.
+func (dbp *Process) continueGoroutineTo(g *G, pc uint64, cfa int64, breakpoints bool) (reached bool, err error) {
+	defer func() {
+		// Always halt process at end of this function.
+		herr := dbp.Halt()
+		// Make sure we clean up the temp breakpoints.
+		cerr := dbp.clearTempBreakpoints()
+		// If we already had an error, return it.
+		if err != nil {
+			return
+		}
+		if herr != nil {
+			err = herr
+			return
+		}
+		if cerr != nil {
+			err = cerr
+		}
+	}()
+
+	if _, err = dbp.SetTempBreakpoint(pc); err != nil {
+		if _, ok := err.(BreakpointExistsError); !ok {
+			return false, err
+		}
+	}
+
+	for _, th := range dbp.Threads {
+		if err = th.Continue(); err != nil {
+			return false, err
+		}
+	}
+
+	for {
+		trapthread, err := dbp.trapWait(-1)
+		if err != nil {
+			return false, err
+		}
+		for _, th := range dbp.Threads {
+			if !th.Stopped() {
+				continue
+			}
+			done, err := th.reached(g, pc, cfa)
+			if err != nil {
+				return false, err
+			}
+			if done {
+				th.CurrentBreakpoint = nil
+				return true, dbp.SwitchThread(th.Id)
+			}
+			if th == trapthread {
+				stop, err := th.trapStops(breakpoints)
+				if err != nil {
+					return false, err
+				}
+				if stop {
+					return false, dbp.SwitchThread(th.Id)
+				}
+			}
+			// Either another goroutine, or a recursive call reaching
+			// the same address.
+			if err = th.Continue(); err != nil {
+				return false, err
+			}
+		}
+	}
+}
+
+// Reports whether the event that stopped thread, returned by trapWait,
+// stops the process: a breakpoint or a watchpoint for which processHit
+// says so if breakpoints is true, or anything else than a breakpoint or a
+// watchpoint, like a syscall catchpoint, a signal with a stop policy or a
+// manual stop.
+func (thread *Thread) trapStops(breakpoints bool) (bool, error) {
+	var (
+		stop bool
+		err  error
+	)
+	switch {
+	case !breakpoints && (thread.CurrentBreakpoint != nil || thread.CurrentWatchpoint != nil):
+		thread.CurrentBreakpoint = nil
+		thread.CurrentWatchpoint = nil
+	case thread.CurrentBreakpoint != nil:
+		if !thread.CurrentBreakpoint.Temp {
+			stop, err = thread.CurrentBreakpoint.processHit(thread)
+		}
+		if !stop {
+			thread.CurrentBreakpoint = nil
+		}
+	case thread.CurrentWatchpoint != nil:
+		stop, err = thread.CurrentWatchpoint.processHit(thread)
+		if !stop {
+			thread.CurrentWatchpoint = nil
+		}
+	default:
+		stop = true
+	}
+	return stop, err
+}
+
+// Returns true if the thread is running goroutine g and is stopped at
+// pc, in the frame with the given CFA if cfa is not zero.
+func (thread *Thread) reached(g *G, pc uint64, cfa int64) (bool, error) {
+	curpc, err := thread.PC()
+	if err != nil {
+		return false, err
+	}
+	if curpc != pc {
+		return false, nil
+	}
+	tg, err := thread.GetG()
+	if err != nil {
+		return false, err
+	}
+	if tg.Id != g.Id {
+		return false, nil
+	}
+	if cfa == 0 {
+		return true, nil
+	}
+	frames, err := thread.Stacktrace(0)
+	if err != nil {
+		return false, err
+	}
+	return frames[0].CFA == cfa, nil
+}
+
+// Step continues the current goroutine to the next source line,
+// entering function calls. Functions of the runtime are stepped over.
+func (dbp *Process) Step() error {
+	return dbp.run(dbp.step)
+}
+
+func (dbp *Process) step() error {
+	thread := dbp.CurrentThread
+	if thread.blocked() {
+		return dbp.next()
+	}
+	start, err := thread.Location()
+	if err != nil {
+		return err
+	}
+	if start.Fn == nil || filepath.Ext(start.File) != ".go" {
+		return dbp.next()
+	}
+
+	// Fetched the first time the goroutine has to be resumed.
+	var g *G
+	getG := func() (*G, error) {
+		if g != nil {
+			return g, nil
+		}
+		var err error
+		g, err = thread.GetG()
+		return g, err
+	}
+
+	for {
+		if err := thread.Step(); err != nil {
+			return err
+		}
+		loc, err := thread.Location()
+		if err != nil {
+			return err
+		}
+		if loc.Fn == nil || loc.File == "<autogenerated>" {
+			// Wrappers generated by the compiler call the function being
+			// stepped into, step through them.
+			continue
+		}
+
+		if loc.PC == loc.Fn.Entry {
+			// Entered a function call.
+			if !stepInto(loc) {
+				frames, err := thread.Stacktrace(1)
+				if err != nil {
+					return err
+				}
+				if reached, err := dbp.runToReturn(frames); !reached || err != nil {
+					return err
+				}
+				thread = dbp.CurrentThread
+				continue
+			}
+			pc, err := dbp.FindFunctionLocation(loc.Fn.Name, true, 0)
+			if err != nil || pc == loc.PC {
+				return nil
+			}
+			// Skip the prologue.
+			if _, err := getG(); err != nil {
+				return err
+			}
+			_, err = dbp.continueGoroutineTo(g, pc, 0, true)
+			return err
+		}
+
+		if loc.Fn != start.Fn {
+			// Returned to the caller.
+			return nil
+		}
+		if loc.Line != start.Line && loc.Line != 0 {
+			return nil
+		}
+	}
+}
+
+// Returns true if step should stop in the function starting at loc,
+// runtime functions and functions without Go source are stepped over.
+func stepInto(loc *Location) bool {
+	if strings.HasPrefix(loc.Fn.Name, "runtime.") {
+		return false
+	}
+	return filepath.Ext(loc.File) == ".go"
+}
+
+// RunTo resumes the process until it reaches one of pcs. The process
+// can also stop before, for example at a breakpoint, the temporary
+// breakpoints set at pcs are cleared in any case.
+func (dbp *Process) RunTo(pcs []uint64) error {
+	targets := make(map[uint64]int64, len(pcs))
+	for _, pc := range pcs {
+		targets[pc] = 0
+	}
+	return dbp.runTo(targets, 0)
+}
+
+// RunPastLine resumes the process until the current goroutine reaches a
+// line greater than the current one in the current frame, or the current
+// function returns. Used to run until the end of a loop.
+func (dbp *Process) RunPastLine() error {
+	thread := dbp.CurrentThread
+	loc, err := thread.Location()
+	if err != nil {
+		return err
+	}
+	frames, err := thread.Stacktrace(1)
+	if err != nil {
+		return err
+	}
+	fde, err := dbp.frameEntries.FDEForPC(loc.PC)
+	if err != nil {
+		return err
+	}
+	g, err := thread.GetG()
+	if err != nil {
+		return err
+	}
+
+	// Addresses of the function where a line greater than the current
+	// one starts.
+	targets := make(map[uint64]int64)
+	lastLine := 0
+	for pc := fde.Begin(); pc < fde.End(); pc++ {
+		_, line, _ := dbp.goSymTable.PCToLine(pc)
+		if line != lastLine && line > loc.Line {
+			targets[pc] = frames[0].CFA
+		}
+		lastLine = line
+	}
+	if len(frames) > 1 {
+		if fn := frames[1].Current.Fn; fn == nil || fn.Name != "runtime.goexit" {
+			targets[frames[1].Current.PC] = frames[1].CFA
+		}
+	}
+	return dbp.runTo(targets, g.Id)
+}
+
+// Jump moves the current thread to pc without executing the code in
+// between, pc must be in the current function. The frame must have the
+// same size at pc as at the current instruction, otherwise the stack
+// would be inconsistent after the jump.
+func (dbp *Process) Jump(pc uint64) error {
+	return dbp.run(func() error { return dbp.jump(pc) })
+}
+
+func (dbp *Process) jump(pc uint64) error {
+	thread := dbp.CurrentThread
+	curpc, err := thread.PC()
+	if err != nil {
+		return err
+	}
+	fde, err := dbp.frameEntries.FDEForPC(curpc)
+	if err != nil {
+		return err
+	}
+	fn := dbp.goSymTable.PCToFunc(curpc)
+	if fn == nil {
+		return fmt.Errorf("could not find function at %#x", curpc)
+	}
+	if !fde.Cover(pc) {
+		return fmt.Errorf("can not jump to %#x, it is outside of %s", pc, fn.Name)
+	}
+	curoff, _ := fde.ReturnAddressOffset(curpc)
+	off, _ := fde.ReturnAddressOffset(pc)
+	if curoff != off {
+		return fmt.Errorf("can not jump to %#x, the frame of %s is not set up the same way there", pc, fn.Name)
+	}
+	dbp.allGCache = nil
+	return thread.SetPC(pc)
+}
+
+// Continues the process with temporary breakpoints set at the addresses
+// in targets, each one restricted to the frame with the associated CFA
+// if it is not zero, and to goroutine goid if it is not zero.
+func (dbp *Process) runTo(targets map[uint64]int64, goid int) (err error) {
+	defer func() {
+		for _, th := range dbp.Threads {
+			if th.CurrentBreakpoint != nil && th.CurrentBreakpoint.Temp {
+				th.CurrentBreakpoint = nil
+			}
+		}
+		if cerr := dbp.clearTempBreakpoints(); err == nil {
+			err = cerr
+		}
+	}()
+	for pc, cfa := range targets {
+		// A breakpoint already at pc gets a temporary breakpoint sharing
+		// its address, which stops the process even if the breakpoint
+		// does not.
+		bp, err := dbp.SetTempBreakpoint(pc)
+		if err != nil {
+			return err
+		}
+		bp.GoroutineID = goid
+		bp.cfa = cfa
+	}
+	return dbp.Continue()
+}
+
 func (dbp *Process) setChanRecvBreakpoints() (int, error) {
 	var count int
 	allg, err := dbp.GoroutinesInfo()
@@ -352,40 +852,167 @@ func (dbp *Process) setChanRecvBreakpoints() (int, error) {
 
 // Resume process.
 func (dbp *Process) Continue() error {
-	for _, thread := range dbp.Threads {
-		err := thread.Continue()
-		if err != nil {
-			return fmt.Errorf("could not continue thread %d %s", thread.Id, err)
-		}
-	}
 	return dbp.run(func() error {
-		thread, err := dbp.trapWait(-1)
-		if err != nil {
-			return err
-		}
-		if err := dbp.Halt(); err != nil {
-			return err
-		}
-		dbp.SwitchThread(thread.Id)
-		loc, err := thread.Location()
-		if err != nil {
-			return err
+		if dbp.hasSoftwareWatchpoints() {
+			return dbp.continueSoftwareWatch()
 		}
-		// Check to see if we hit a runtime.breakpoint
-		if loc.Fn != nil && loc.Fn.Name == "runtime.breakpoint" {
-			// Step twice to get back to user code
-			for i := 0; i < 2; i++ {
-				if err = thread.Step(); err != nil {
+		for {
+			// Step over breakpoints before resuming any thread, otherwise
+			// a thread that is already running could go through a
+			// breakpoint while it is temporarily cleared.
+			for _, thread := range dbp.Threads {
+				thread.CurrentBreakpoint = nil
+				thread.CurrentWatchpoint = nil
+				thread.CurrentSyscall = nil
+				pc, err := thread.PC()
+				if err != nil {
+					return fmt.Errorf("could not continue thread %d %s", thread.Id, err)
+				}
+				if _, ok := dbp.findEnabledBreakpoint(pc); !ok {
+					continue
+				}
+				if err := thread.Step(); err != nil {
+					return fmt.Errorf("could not continue thread %d %s", thread.Id, err)
+				}
+				// The instruction we stepped over could have triggered a watchpoint.
+				wp, err := thread.watchpointHit()
+				if err != nil {
 					return err
 				}
+				if wp != nil {
+					stop, err := wp.processHit(thread)
+					if stop || err != nil {
+						thread.CurrentWatchpoint = wp
+						dbp.SwitchThread(thread.Id)
+						return err
+					}
+				}
 			}
+			for _, thread := range dbp.Threads {
+				// ESRCH means that the process is exiting and the thread was
+				// killed after an other thread was resumed, trapWait will
+				// report the exit.
+				if err := thread.resume(); err != nil && err != sys.ESRCH {
+					return fmt.Errorf("could not continue thread %d %s", thread.Id, err)
+				}
+			}
+			thread, err := dbp.trapWait(-1)
+			if err != nil {
+				return err
+			}
+			if err := dbp.Halt(); err != nil {
+				return err
+			}
+			loc, err := thread.Location()
+			if err != nil {
+				return err
+			}
+			// Check to see if we hit a runtime.breakpoint
+			if loc.Fn != nil && loc.Fn.Name == "runtime.breakpoint" {
+				// Step twice to get back to user code
+				for i := 0; i < 2; i++ {
+					if err = thread.Step(); err != nil {
+						return err
+					}
+				}
+			}
+			stopthread, err := dbp.processBreakpointHits(thread)
+			if stopthread != nil {
+				dbp.SwitchThread(stopthread.Id)
+			}
+			if err != nil {
+				return err
+			}
+			if stopthread == nil {
+				// All hits were filtered out by a breakpoint condition,
+				// hit count or read watchpoint, resume without reporting
+				// this stop to the caller.
+				dbp.allGCache = nil
+				continue
+			}
+			return nil
 		}
-		return nil
 	})
 }
 
+// Records the breakpoint and watchpoint hits of all threads and returns
+// the thread the process should stop on, or nil if it should be resumed.
+// Threads other than trapthread may have hit a breakpoint concurrently
+// and been stopped by Halt before their SIGTRAP could be reported, so
+// they are checked as well and moved back to the breakpoint address.
+func (dbp *Process) processBreakpointHits(trapthread *Thread) (*Thread, error) {
+	var stopthread *Thread
+	if trapthread.CurrentBreakpoint == nil && trapthread.CurrentWatchpoint == nil {
+		// Manual stop or runtime.breakpoint.
+		stopthread = trapthread
+	}
+
+	threads := make([]*Thread, 0, len(dbp.Threads))
+	threads = append(threads, trapthread)
+	for _, th := range dbp.Threads {
+		if th != trapthread {
+			threads = append(threads, th)
+		}
+	}
+
+	for _, th := range threads {
+		if th != trapthread {
+			pc, err := th.PC()
+			if err != nil {
+				return stopthread, err
+			}
+			if bp, ok := dbp.Breakpoints[pc-uint64(dbp.arch.BreakpointSize())]; ok && bp.active() {
+				if err := th.SetPC(bp.Addr); err != nil {
+					return stopthread, err
+				}
+				if !bp.Enabled {
+					bp = bp.temp
+				}
+				th.CurrentBreakpoint = bp
+			} else {
+				th.CurrentWatchpoint, err = th.watchpointHit()
+				if err != nil {
+					return stopthread, err
+				}
+			}
+		}
+
+		var (
+			stop bool
+			err  error
+		)
+		switch {
+		case th.CurrentBreakpoint != nil:
+			stop, err = th.CurrentBreakpoint.processHit(th)
+			if !stop && err == nil && th.CurrentBreakpoint.temp != nil {
+				// The temporary breakpoint sharing the address has its
+				// own goroutine and frame.
+				th.CurrentBreakpoint = th.CurrentBreakpoint.temp
+				stop, err = th.CurrentBreakpoint.processHit(th)
+			}
+			if !stop {
+				th.CurrentBreakpoint = nil
+			}
+		case th.CurrentWatchpoint != nil:
+			stop, err = th.CurrentWatchpoint.processHit(th)
+			if !stop {
+				th.CurrentWatchpoint = nil
+			}
+		default:
+			continue
+		}
+		if err != nil {
+			return th, err
+		}
+		if stop && stopthread == nil {
+			stopthread = th
+		}
+	}
+	return stopthread, nil
+}
+
 // Single step, will execute a single instruction.
-func (dbp *Process) Step() (err error) {
+func (dbp *Process) StepInstruction() (err error) {
 	fn := func() error {
 		for _, th := range dbp.Threads {
 			if th.blocked() {
@@ -394,6 +1021,16 @@ func (dbp *Process) Step() (err error) {
 			if err := th.Step(); err != nil {
 				return err
 			}
+			wp, err := th.watchpointHit()
+			if err != nil {
+				return err
+			}
+			if wp != nil {
+				if _, err := wp.processHit(th); err != nil {
+					return err
+				}
+				th.CurrentWatchpoint = wp
+			}
 		}
 		return nil
 	}
@@ -524,6 +1161,23 @@ func (dbp *Process) DwarfReader() *reader.Reader {
 	return reader.New(dbp.dwarf)
 }
 
+// Finds the type with the given name in the debug info.
+func (dbp *Process) findType(name string) (dwarf.Type, error) {
+	rdr := dbp.DwarfReader()
+	for {
+		entry, err := rdr.SeekToTypeNamed(name)
+		if err != nil {
+			return nil, err
+		}
+		switch entry.Tag {
+		case dwarf.TagCompileUnit, dwarf.TagSubprogram, dwarf.TagVariable, dwarf.TagFormalParameter, dwarf.TagMember:
+			// Not a type, keep looking.
+			continue
+		}
+		return dbp.dwarf.Type(entry.Offset)
+	}
+}
+
 // Returns list of source files that comprise the debugged binary.
 func (dbp *Process) Sources() map[string]*gosym.Obj {
 	return dbp.goSymTable.Files
@@ -562,6 +1216,20 @@ func (dbp *Process) FindBreakpoint(pc uint64) (*Breakpoint, bool) {
 	return nil, false
 }
 
+// Finds the breakpoint for the given pc, ignoring disabled
+// breakpoints since they can not be hit. The temporary breakpoint
+// sharing the address of a disabled breakpoint is returned instead.
+func (dbp *Process) findEnabledBreakpoint(pc uint64) (*Breakpoint, bool) {
+	bp, ok := dbp.FindBreakpoint(pc)
+	if !ok || !bp.active() {
+		return nil, false
+	}
+	if !bp.Enabled {
+		bp = bp.temp
+	}
+	return bp, true
+}
+
 // Returns a new Process struct.
 func initializeDebugProcess(dbp *Process, path string, attach bool) (*Process, error) {
 	if attach {
@@ -614,6 +1282,20 @@ func initializeDebugProcess(dbp *Process, path string, attach bool) (*Process, e
 func (dbp *Process) clearTempBreakpoints() error {
 	for _, bp := range dbp.Breakpoints {
 		if !bp.Temp {
+			if bp.temp == nil {
+				continue
+			}
+			bp.temp = nil
+			if !bp.Enabled && !dbp.exited {
+				if _, err := bp.Clear(dbp.CurrentThread); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if dbp.exited {
+			// Nothing to restore in memory.
+			delete(dbp.Breakpoints, bp.Addr)
 			continue
 		}
 		if _, err := dbp.ClearBreakpoint(bp.Addr); err != nil {
@@ -633,13 +1315,21 @@ func (dbp *Process) handleBreakpointOnThread(id int) (*Thread, error) {
 		return nil, err
 	}
 	// Check to see if we have hit a breakpoint.
-	if bp, ok := dbp.FindBreakpoint(pc); ok {
+	if bp, ok := dbp.findEnabledBreakpoint(pc); ok {
 		thread.CurrentBreakpoint = bp
 		if err = thread.SetPC(bp.Addr); err != nil {
 			return nil, err
 		}
 		return thread, nil
 	}
+	wp, err := thread.watchpointHit()
+	if err != nil {
+		return nil, err
+	}
+	if wp != nil {
+		thread.CurrentWatchpoint = wp
+		return thread, nil
+	}
 	if dbp.halt {
 		return thread, nil
 	}
@@ -660,8 +1350,14 @@ func (dbp *Process) run(fn func() error) error {
 	if dbp.exited {
 		return fmt.Errorf("process has already exited")
 	}
+	dbp.Forked = nil
+	dbp.ExecPath = ""
+	dbp.Signals = nil
 	for _, th := range dbp.Threads {
 		th.CurrentBreakpoint = nil
+		th.CurrentWatchpoint = nil
+		th.CurrentSyscall = nil
+		th.ReturnValues = nil
 	}
 	if err := fn(); err != nil {
 		return err
@@ -120,6 +120,10 @@ func (dbp *Process) Kill() (err error) {
 	return
 }
 
+func (dbp *Process) waitStep() (*Thread, bool, error) {
+	return nil, false, fmt.Errorf("asynchronous single step not supported on darwin")
+}
+
 func (dbp *Process) requestManualStop() (err error) {
 	var (
 		task          = C.mach_port_t(dbp.os.task)
@@ -70,7 +70,16 @@ func (dbp *Process) Kill() (err error) {
 	if !dbp.Threads[dbp.Pid].Stopped() {
 		return errors.New("process must be stopped in order to kill it")
 	}
-	if err = sys.Kill(-dbp.Pid, sys.SIGKILL); err != nil {
+	if err = dbp.clearCheckpoints(); err != nil {
+		return
+	}
+	// Forked children and copies of checkpoints share the process group
+	// of the launched process, only its leader kills the whole group.
+	pid := dbp.Pid
+	if pgid, err := sys.Getpgid(dbp.Pid); err == nil && pgid == dbp.Pid {
+		pid = -dbp.Pid
+	}
+	if err = sys.Kill(pid, sys.SIGKILL); err != nil {
 		return errors.New("could not deliver signal " + err.Error())
 	}
 	if _, _, err = wait(dbp.Pid, dbp.Pid, 0); err != nil {
@@ -110,12 +119,12 @@ func (dbp *Process) addThread(tid int, attach bool) (*Thread, error) {
 		}
 	}
 
-	dbp.execPtraceFunc(func() { err = syscall.PtraceSetOptions(tid, syscall.PTRACE_O_TRACECLONE) })
+	dbp.execPtraceFunc(func() { err = syscall.PtraceSetOptions(tid, dbp.ptraceOptions()) })
 	if err == syscall.ESRCH {
 		if _, _, err = wait(tid, dbp.Pid, 0); err != nil {
 			return nil, fmt.Errorf("error while waiting after adding thread: %d %s", tid, err)
 		}
-		dbp.execPtraceFunc(func() { err = syscall.PtraceSetOptions(tid, syscall.PTRACE_O_TRACECLONE) })
+		dbp.execPtraceFunc(func() { err = syscall.PtraceSetOptions(tid, dbp.ptraceOptions()) })
 		if err == syscall.ESRCH {
 			return nil, err
 		}
@@ -124,11 +133,18 @@ func (dbp *Process) addThread(tid int, attach bool) (*Thread, error) {
 		}
 	}
 
-	dbp.Threads[tid] = &Thread{
+	thread := &Thread{
 		Id:  tid,
 		dbp: dbp,
 		os:  new(OSSpecificDetails),
 	}
+	// Debug registers are not inherited by new threads.
+	for _, wp := range dbp.Watchpoints {
+		if err := thread.setWatchpoint(wp); err != nil {
+			return nil, err
+		}
+	}
+	dbp.Threads[tid] = thread
 	if dbp.CurrentThread == nil {
 		dbp.SwitchThread(tid)
 	}
@@ -238,6 +254,14 @@ func (dbp *Process) parseDebugLineInfo(exe *elf.File, wg *sync.WaitGroup) {
 }
 
 func (dbp *Process) trapWait(pid int) (*Thread, error) {
+	if th := dbp.haltThread; th != nil {
+		dbp.haltThread = nil
+		if dbp.halt {
+			// The manual stop was received while single stepping th.
+			dbp.halt = false
+			return th, nil
+		}
+	}
 	for {
 		wpid, status, err := wait(pid, dbp.Pid, 0)
 		if err != nil {
@@ -250,10 +274,14 @@ func (dbp *Process) trapWait(pid int) (*Thread, error) {
 		if ok {
 			th.Status = status
 		}
-		if status.Exited() {
+		if status.Exited() || status.Signaled() {
 			if wpid == dbp.Pid {
 				dbp.exited = true
-				return nil, ProcessExitedError{Pid: wpid, Status: status.ExitStatus()}
+				pe := ProcessExitedError{Pid: wpid, Status: status.ExitStatus()}
+				if status.Signaled() {
+					pe.Signal = status.Signal()
+				}
+				return nil, pe
 			}
 			delete(dbp.Threads, wpid)
 			continue
@@ -291,6 +319,45 @@ func (dbp *Process) trapWait(pid int) (*Thread, error) {
 			// Sometimes we get an unknown thread, ignore it?
 			continue
 		}
+		if status.StopSignal() == sys.SIGTRAP|0x80 {
+			// System call entry or exit, see PTRACE_O_TRACESYSGOOD.
+			th.running = false
+			stop, err := th.processSyscallStop()
+			if err != nil {
+				return nil, err
+			}
+			if stop {
+				return th, nil
+			}
+			if err := th.resume(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		// Signals and events are only reported outside of system calls,
+		// an exec is reported before the exit of execve.
+		th.os.inSyscall = false
+		if status.StopSignal() == sys.SIGTRAP {
+			switch status.TrapCause() {
+			case sys.PTRACE_EVENT_FORK, sys.PTRACE_EVENT_VFORK:
+				stop, err := dbp.handleFork(th, status.TrapCause() == sys.PTRACE_EVENT_VFORK)
+				if err != nil {
+					return nil, err
+				}
+				if stop {
+					th.running = false
+					return th, nil
+				}
+				continue
+			case sys.PTRACE_EVENT_EXEC:
+				if err := dbp.handleExec(); err != nil {
+					return nil, err
+				}
+				th = dbp.CurrentThread
+				th.running = false
+				return th, nil
+			}
+		}
 		if status.StopSignal() == sys.SIGTRAP && dbp.halt {
 			th.running = false
 			dbp.halt = false
@@ -301,7 +368,10 @@ func (dbp *Process) trapWait(pid int) (*Thread, error) {
 			return dbp.handleBreakpointOnThread(wpid)
 		}
 		if th != nil {
-			// TODO(dp) alert user about unexpected signals here.
+			if th.receiveSignal(status.StopSignal()) {
+				th.running = false
+				return th, nil
+			}
 			if err := th.Continue(); err != nil {
 				return nil, err
 			}
@@ -309,6 +379,24 @@ func (dbp *Process) trapWait(pid int) (*Thread, error) {
 	}
 }
 
+// Waits for any thread to stop after a single step started by
+// startStep, returns the thread and whether its step has been completed.
+func (dbp *Process) waitStep() (*Thread, bool, error) {
+	wpid, status, err := wait(-1, dbp.Pid, 0)
+	if err != nil {
+		return nil, false, err
+	}
+	th, ok := dbp.Threads[wpid]
+	if !ok {
+		// A new thread stopping before the clone event
+		// of its parent has been reported, it will be
+		// added once the parent completes its step.
+		return nil, false, nil
+	}
+	done, err := th.stepStatus(status)
+	return th, done, err
+}
+
 func status(pid int) rune {
 	f, err := os.Open(fmt.Sprintf("/proc/%d/stat", pid))
 	if err != nil {
@@ -342,6 +430,9 @@ func wait(pid, tgid, options int) (int, *sys.WaitStatus, error) {
 		// https://sourceware.org/bugzilla/show_bug.cgi?id=12702
 		// https://sourceware.org/bugzilla/show_bug.cgi?id=10095
 		// https://sourceware.org/bugzilla/attachment.cgi?id=5685
+		// The delay between calls starts small since single steps, which
+		// step executes many of, complete almost immediately.
+		delay := time.Millisecond
 		for {
 			wpid, err := sys.Wait4(pid, &s, sys.WNOHANG|sys.WALL|options, nil)
 			if err != nil {
@@ -353,7 +444,10 @@ func wait(pid, tgid, options int) (int, *sys.WaitStatus, error) {
 			if status(pid) == STATUS_ZOMBIE {
 				return pid, nil, nil
 			}
-			time.Sleep(200 * time.Millisecond)
+			time.Sleep(delay)
+			if delay < 200*time.Millisecond {
+				delay *= 2
+			}
 		}
 	}
 }
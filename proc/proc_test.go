@@ -3,12 +3,14 @@ package proc
 import (
 	"bytes"
 	"fmt"
+	"go/parser"
 	"net"
 	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"syscall"
 	"testing"
 	"time"
 
@@ -154,8 +156,8 @@ func TestStep(t *testing.T) {
 		regs := getRegisters(p, t)
 		rip := regs.PC()
 
-		err = p.Step()
-		assertNoError(err, t, "Step()")
+		err = p.StepInstruction()
+		assertNoError(err, t, "StepInstruction()")
 
 		regs = getRegisters(p, t)
 		if rip >= regs.PC() {
@@ -800,3 +802,476 @@ func TestProcessReceivesSIGCHLD(t *testing.T) {
 		}
 	})
 }
+
+func TestCondBreakpoint(t *testing.T) {
+	withTestProcess("integrationprog", t, func(p *Process, fixture protest.Fixture) {
+		pc, _, err := p.goSymTable.LineToPC(fixture.Source, 15)
+		assertNoError(err, t, "LineToPC()")
+		bp, err := p.SetBreakpoint(pc)
+		assertNoError(err, t, "SetBreakpoint()")
+		bp.Cond, err = parser.ParseExpr("i == 2")
+		assertNoError(err, t, "ParseExpr()")
+
+		assertNoError(p.Continue(), t, "Continue()")
+
+		v, err := evalVariable(p, "i")
+		assertNoError(err, t, "EvalVariable()")
+		if v.Value != "2" {
+			t.Fatalf("Stopped on wrong iteration of the loop: i = %s", v.Value)
+		}
+	})
+}
+
+func TestBreakpointCounts(t *testing.T) {
+	withTestProcess("bpcountstest", t, func(p *Process, fixture protest.Fixture) {
+		addr, _, err := p.goSymTable.LineToPC(fixture.Source, 11)
+		assertNoError(err, t, "LineToPC")
+		bp, err := p.SetBreakpoint(addr)
+		assertNoError(err, t, "SetBreakpoint()")
+
+		for {
+			if err := p.Continue(); err != nil {
+				if _, exited := err.(ProcessExitedError); exited {
+					break
+				}
+				assertNoError(err, t, "Continue()")
+			}
+		}
+
+		t.Logf("TotalHitCount: %d", bp.TotalHitCount)
+		if bp.TotalHitCount != 200 {
+			t.Fatalf("Wrong TotalHitCount for the breakpoint (%d)", bp.TotalHitCount)
+		}
+
+		if len(bp.HitCount) != 2 {
+			t.Fatalf("Wrong number of goroutines for breakpoint (%d)", len(bp.HitCount))
+		}
+
+		for _, v := range bp.HitCount {
+			if v != 100 {
+				t.Fatalf("Wrong HitCount for breakpoint (%v)", bp.HitCount)
+			}
+		}
+	})
+}
+
+func TestBreakpointGoroutineFilter(t *testing.T) {
+	withTestProcess("bpcountstest", t, func(p *Process, fixture protest.Fixture) {
+		addr, _, err := p.goSymTable.LineToPC(fixture.Source, 11)
+		assertNoError(err, t, "LineToPC")
+		bp, err := p.SetBreakpoint(addr)
+		assertNoError(err, t, "SetBreakpoint()")
+
+		assertNoError(p.Continue(), t, "Continue()")
+		g, err := p.CurrentThread.GetG()
+		assertNoError(err, t, "GetG()")
+		bp.GoroutineID = g.Id
+
+		stops := 1
+		for {
+			if err := p.Continue(); err != nil {
+				if _, exited := err.(ProcessExitedError); exited {
+					break
+				}
+				assertNoError(err, t, "Continue()")
+			}
+			stops++
+			g, err := p.CurrentThread.GetG()
+			assertNoError(err, t, "GetG()")
+			if g.Id != bp.GoroutineID {
+				t.Fatalf("Stopped on goroutine %d (expected %d)", g.Id, bp.GoroutineID)
+			}
+		}
+
+		if stops != 100 {
+			t.Fatalf("Wrong number of stops: %d", stops)
+		}
+	})
+}
+
+func TestBreakpointCreatedAt(t *testing.T) {
+	withTestProcess("bpcountstest", t, func(p *Process, fixture protest.Fixture) {
+		addr, _, err := p.goSymTable.LineToPC(fixture.Source, 11)
+		assertNoError(err, t, "LineToPC")
+		bp, err := p.SetBreakpoint(addr)
+		assertNoError(err, t, "SetBreakpoint()")
+		bp.CreatedAt = &GoStatementLocation{File: fixture.Source, Line: 20}
+
+		stops := 0
+		for {
+			if err := p.Continue(); err != nil {
+				if _, exited := err.(ProcessExitedError); exited {
+					break
+				}
+				assertNoError(err, t, "Continue()")
+			}
+			stops++
+			g, err := p.CurrentThread.GetG()
+			assertNoError(err, t, "GetG()")
+			if _, line, _ := p.goSymTable.PCToLine(g.GoPC); line != 20 {
+				t.Fatalf("Stopped on goroutine created at line %d", line)
+			}
+		}
+
+		if stops != 100 {
+			t.Fatalf("Wrong number of stops: %d", stops)
+		}
+	})
+}
+
+func TestBreakpointIgnoreCount(t *testing.T) {
+	withTestProcess("bpcountstest", t, func(p *Process, fixture protest.Fixture) {
+		addr, _, err := p.goSymTable.LineToPC(fixture.Source, 11)
+		assertNoError(err, t, "LineToPC")
+		bp, err := p.SetBreakpoint(addr)
+		assertNoError(err, t, "SetBreakpoint()")
+		bp.IgnoreCount = 150
+		bp.StopEvery = 10
+
+		stops := 0
+		for {
+			if err := p.Continue(); err != nil {
+				if _, exited := err.(ProcessExitedError); exited {
+					break
+				}
+				assertNoError(err, t, "Continue()")
+			}
+			stops++
+		}
+
+		if stops != 5 {
+			t.Fatalf("Wrong number of stops: %d", stops)
+		}
+	})
+}
+
+func TestDisableBreakpoint(t *testing.T) {
+	withTestProcess("watchpointtest", t, func(p *Process, fixture protest.Fixture) {
+		loopaddr, _, err := p.goSymTable.LineToPC(fixture.Source, 9)
+		assertNoError(err, t, "LineToPC")
+		printaddr, _, err := p.goSymTable.LineToPC(fixture.Source, 11)
+		assertNoError(err, t, "LineToPC")
+		loopbp, err := p.SetBreakpoint(loopaddr)
+		assertNoError(err, t, "SetBreakpoint()")
+		printbp, err := p.SetBreakpoint(printaddr)
+		assertNoError(err, t, "SetBreakpoint()")
+
+		assertNoError(p.Continue(), t, "Continue()")
+		if p.CurrentBreakpoint() != loopbp {
+			t.Fatalf("Not stopped at loop breakpoint: %v", p.CurrentBreakpoint())
+		}
+
+		_, err = p.DisableBreakpoint(loopbp.Addr)
+		assertNoError(err, t, "DisableBreakpoint()")
+		if bp, ok := p.FindBreakpointByID(loopbp.ID); !ok || bp.Enabled {
+			t.Fatalf("Breakpoint %d not kept or still enabled", loopbp.ID)
+		}
+		data, err := p.CurrentThread.readMemory(uintptr(loopbp.Addr), len(loopbp.OriginalData))
+		assertNoError(err, t, "readMemory()")
+		if !bytes.Equal(data, loopbp.OriginalData) {
+			t.Fatalf("Original instruction not restored: %#v (expected %#v)", data, loopbp.OriginalData)
+		}
+
+		assertNoError(p.Continue(), t, "Continue()")
+		if p.CurrentBreakpoint() != printbp {
+			t.Fatalf("Not stopped at print breakpoint: %v", p.CurrentBreakpoint())
+		}
+		if loopbp.TotalHitCount != 1 {
+			t.Fatalf("Disabled breakpoint was hit: %d hits", loopbp.TotalHitCount)
+		}
+
+		_, err = p.EnableBreakpoint(loopbp.Addr)
+		assertNoError(err, t, "EnableBreakpoint()")
+		data, err = p.CurrentThread.readMemory(uintptr(loopbp.Addr), len(loopbp.OriginalData))
+		assertNoError(err, t, "readMemory()")
+		if !bytes.Equal(data, p.arch.BreakpointInstruction()) {
+			t.Fatalf("Breakpoint instruction not written: %#v", data)
+		}
+	})
+}
+
+func TestNextDisabledBreakpoint(t *testing.T) {
+	withTestProcess("testnextprog", t, func(p *Process, fixture protest.Fixture) {
+		_, err := setFunctionBreakpoint(p, "main.testnext")
+		assertNoError(err, t, "SetBreakpoint()")
+		assertNoError(p.Continue(), t, "Continue()")
+		if _, ln := currentLineNumber(p, t); ln != 19 {
+			t.Fatalf("Program not stopped at correct spot expected 19 was %d", ln)
+		}
+
+		addr, _, err := p.goSymTable.LineToPC(fixture.Source, 20)
+		assertNoError(err, t, "LineToPC")
+		bp, err := p.SetBreakpoint(addr)
+		assertNoError(err, t, "SetBreakpoint()")
+		_, err = p.DisableBreakpoint(addr)
+		assertNoError(err, t, "DisableBreakpoint()")
+
+		assertNoError(p.Next(), t, "Next()")
+		if f, ln := currentLineNumber(p, t); ln != 20 {
+			t.Fatalf("Program did not continue to correct next location expected 20 was %s:%d", filepath.Base(f), ln)
+		}
+		if bp.Enabled || bp.temp != nil || bp.TotalHitCount != 0 {
+			t.Fatalf("Disabled breakpoint changed by next: %#v", bp)
+		}
+		data, err := p.CurrentThread.readMemory(uintptr(addr), len(bp.OriginalData))
+		assertNoError(err, t, "readMemory()")
+		if !bytes.Equal(data, bp.OriginalData) {
+			t.Fatalf("Original instruction not restored: %#v (expected %#v)", data, bp.OriginalData)
+		}
+		for _, bp := range p.Breakpoints {
+			if bp.Temp {
+				t.Fatalf("Temporary breakpoint not cleared: %v", bp)
+			}
+		}
+	})
+}
+
+func TestWatchpoint(t *testing.T) {
+	withTestProcess("watchpointtest", t, func(p *Process, fixture protest.Fixture) {
+		bp, err := setFunctionBreakpoint(p, "main.main")
+		assertNoError(err, t, "SetBreakpoint()")
+		assertNoError(p.Continue(), t, "Continue()")
+		_, err = p.ClearBreakpoint(bp.Addr)
+		assertNoError(err, t, "ClearBreakpoint()")
+
+		scope, err := p.CurrentThread.Scope()
+		assertNoError(err, t, "Scope()")
+		wp, err := p.SetWatchpoint(scope, "main.counter", WatchWrite)
+		assertNoError(err, t, "SetWatchpoint()")
+		if wp.Size != 8 {
+			t.Fatalf("Wrong size for watchpoint: %d", wp.Size)
+		}
+
+		for _, value := range []string{"1", "3", "6"} {
+			assertNoError(p.Continue(), t, "Continue()")
+			if p.CurrentWatchpoint() != wp {
+				t.Fatalf("Not stopped at watchpoint: %v", p.CurrentWatchpoint())
+			}
+			if wp.Value != value {
+				t.Fatalf("Wrong value after watchpoint hit: %s (expected %s, old value %s)", wp.Value, value, wp.OldValue)
+			}
+		}
+
+		_, err = p.ClearWatchpoint(wp.ID)
+		assertNoError(err, t, "ClearWatchpoint()")
+		if _, exited := p.Continue().(ProcessExitedError); !exited {
+			t.Fatal("Process did not exit after clearing the watchpoint")
+		}
+	})
+}
+
+func TestSoftwareWatchpoint(t *testing.T) {
+	withTestProcess("swwatchpointtest", t, func(p *Process, fixture protest.Fixture) {
+		bp, err := setFunctionBreakpoint(p, "main.main")
+		assertNoError(err, t, "SetBreakpoint()")
+		assertNoError(p.Continue(), t, "Continue()")
+		_, err = p.ClearBreakpoint(bp.Addr)
+		assertNoError(err, t, "ClearBreakpoint()")
+
+		scope, err := p.CurrentThread.Scope()
+		assertNoError(err, t, "Scope()")
+		wp, err := p.SetWatchpoint(scope, "main.p", WatchWrite)
+		assertNoError(err, t, "SetWatchpoint()")
+		if !wp.Software {
+			t.Fatal("Watchpoint on 24 bytes struct is not a software watchpoint")
+		}
+
+		for _, field := range []string{"Y", "Y", "Y", "Z"} {
+			assertNoError(p.Continue(), t, "Continue()")
+			if p.CurrentWatchpoint() != wp {
+				t.Fatalf("Not stopped at watchpoint: %v", p.CurrentWatchpoint())
+			}
+			if len(wp.ChangedFields) != 1 || wp.ChangedFields[0] != field {
+				t.Fatalf("Wrong changed fields %v (expected %s)", wp.ChangedFields, field)
+			}
+		}
+		if _, exited := p.Continue().(ProcessExitedError); !exited {
+			t.Fatal("Process did not exit")
+		}
+	})
+}
+
+func TestStepOutRecursive(t *testing.T) {
+	withTestProcess("stepoutprog", t, func(p *Process, fixture protest.Fixture) {
+		addr, _, err := p.goSymTable.LineToPC(fixture.Source, 6)
+		assertNoError(err, t, "LineToPC")
+		_, err = p.SetBreakpoint(addr)
+		assertNoError(err, t, "SetBreakpoint()")
+		// Stop in fact(4), called by fact(5).
+		assertNoError(p.Continue(), t, "Continue()")
+		assertNoError(p.Continue(), t, "Continue()")
+		_, err = p.ClearBreakpoint(addr)
+		assertNoError(err, t, "ClearBreakpoint()")
+
+		frames, err := p.CurrentThread.Stacktrace(10)
+		assertNoError(err, t, "Stacktrace()")
+
+		assertNoError(p.StepOut(), t, "StepOut()")
+
+		// The recursive calls of fact(4) return to the same address, make
+		// sure we stopped when fact(4) itself returned.
+		pc := currentPC(p, t)
+		if pc != frames[1].Current.PC {
+			t.Fatalf("Wrong PC after StepOut %#x, expected %#x", pc, frames[1].Current.PC)
+		}
+		newframes, err := p.CurrentThread.Stacktrace(10)
+		assertNoError(err, t, "Stacktrace()")
+		if newframes[0].CFA != frames[1].CFA {
+			t.Fatalf("Wrong frame after StepOut, CFA %#x expected %#x", newframes[0].CFA, frames[1].CFA)
+		}
+
+		retvals := p.CurrentThread.ReturnValues
+		if len(retvals) != 1 || retvals[0].Value != "24" {
+			t.Fatalf("Wrong return values: %#v", retvals)
+		}
+	})
+}
+
+func TestFollowForkBoth(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("following children is only supported on linux")
+	}
+	withTestProcess("forkprog", t, func(p *Process, fixture protest.Fixture) {
+		assertNoError(p.SetFollowMode(FollowBoth), t, "SetFollowMode()")
+		assertNoError(p.Continue(), t, "Continue()")
+		if len(p.Forked) != 1 {
+			t.Fatalf("expected one forked child, got %d", len(p.Forked))
+		}
+		child := p.Forked[0]
+		defer child.Kill()
+		if child.arch == p.arch {
+			t.Fatal("the child shares the debug registers of the parent")
+		}
+
+		// The child executes the fixture again.
+		assertNoError(child.Continue(), t, "child Continue()")
+		if child.ExecPath != fixture.Path {
+			t.Fatalf("expected child to execute %s, got %q", fixture.Path, child.ExecPath)
+		}
+		if _, ok := child.Continue().(ProcessExitedError); !ok {
+			t.Fatal("expected child to exit")
+		}
+		if _, ok := p.Continue().(ProcessExitedError); !ok {
+			t.Fatal("expected parent to exit")
+		}
+	})
+}
+
+func TestFollowForkChild(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("following children is only supported on linux")
+	}
+	withTestProcess("forkprog", t, func(p *Process, fixture protest.Fixture) {
+		parent := p.Pid
+		assertNoError(p.SetFollowMode(FollowChild), t, "SetFollowMode()")
+		assertNoError(p.Continue(), t, "Continue()")
+		if p.Pid == parent {
+			t.Fatal("expected to follow the child")
+		}
+		if p.ExecPath != fixture.Path {
+			t.Fatalf("expected child to execute %s, got %q", fixture.Path, p.ExecPath)
+		}
+		if _, ok := p.Continue().(ProcessExitedError); !ok {
+			t.Fatal("expected child to exit")
+		}
+	})
+}
+
+func TestSyscallCatchpoint(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("syscall catchpoints are only supported on linux")
+	}
+	withTestProcess("syscallprog", t, func(p *Process, fixture protest.Fixture) {
+		cp, err := p.SetSyscallCatchpoint([]string{"openat"})
+		assertNoError(err, t, "SetSyscallCatchpoint()")
+
+		// The runtime opens files of its own while starting.
+		path := ""
+		for path != "/dev/null" {
+			assertNoError(p.Continue(), t, "Continue()")
+			s := p.CurrentSyscall()
+			if s == nil || s.Catchpoint != cp || s.Name != "openat" {
+				t.Fatalf("expected to stop at openat, got %v", s)
+			}
+			if s.Exit {
+				continue
+			}
+			data, err := p.CurrentThread.readMemory(uintptr(s.Args[1]), len("/dev/null")+1)
+			assertNoError(err, t, "readMemory()")
+			path = string(data[:len(data)-1])
+		}
+
+		assertNoError(p.Continue(), t, "Continue()")
+		s := p.CurrentSyscall()
+		if s == nil || !s.Exit || s.Name != "openat" {
+			t.Fatalf("expected to stop returning from openat, got %v", s)
+		}
+		if s.Return < 0 {
+			t.Fatalf("openat failed: %d", s.Return)
+		}
+
+		_, err = p.ClearSyscallCatchpoint(cp.ID)
+		assertNoError(err, t, "ClearSyscallCatchpoint()")
+		if _, ok := p.Continue().(ProcessExitedError); !ok {
+			t.Fatal("expected process to exit")
+		}
+	})
+}
+
+func TestSignalStop(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("signals are only handled on linux")
+	}
+	withTestProcess("signalprog", t, func(p *Process, fixture protest.Fixture) {
+		assertNoError(p.SetSignalPolicy(syscall.SIGUSR1, SignalPolicy{Stop: true, Pass: true}), t, "SetSignalPolicy()")
+		assertNoError(p.Continue(), t, "Continue()")
+		if len(p.Signals) != 1 || p.Signals[0].Signal != syscall.SIGUSR1 || !p.Signals[0].Stopped {
+			t.Fatalf("expected to stop for SIGUSR1, got %v", p.Signals)
+		}
+		// The signal is delivered when the process is resumed.
+		pe, ok := p.Continue().(ProcessExitedError)
+		if !ok {
+			t.Fatal("expected process to exit")
+		}
+		if pe.Status != 0 {
+			t.Fatalf("SIGUSR1 was not delivered, exit status %d", pe.Status)
+		}
+	})
+}
+
+func TestSignalDefaultPolicy(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("signals are only handled on linux")
+	}
+	withTestProcess("signalprog", t, func(p *Process, fixture protest.Fixture) {
+		pe, ok := p.Continue().(ProcessExitedError)
+		if !ok {
+			t.Fatal("expected process to exit")
+		}
+		if pe.Status != 0 {
+			t.Fatalf("SIGUSR1 was not delivered, exit status %d", pe.Status)
+		}
+		if len(p.Signals) != 1 || p.Signals[0].Signal != syscall.SIGUSR1 || p.Signals[0].Stopped || !p.Signals[0].Passed {
+			t.Fatalf("expected SIGUSR1 to be printed and passed, got %v", p.Signals)
+		}
+	})
+}
+
+func TestSignalNoPass(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("signals are only handled on linux")
+	}
+	withTestProcess("signalprog", t, func(p *Process, fixture protest.Fixture) {
+		assertNoError(p.SetSignalPolicy(syscall.SIGUSR1, SignalPolicy{Print: true}), t, "SetSignalPolicy()")
+		pe, ok := p.Continue().(ProcessExitedError)
+		if !ok {
+			t.Fatal("expected process to exit")
+		}
+		if pe.Status != 1 {
+			t.Fatalf("SIGUSR1 was delivered, exit status %d", pe.Status)
+		}
+		if len(p.Signals) != 1 || p.Signals[0].Signal != syscall.SIGUSR1 || p.Signals[0].Stopped || p.Signals[0].Passed {
+			t.Fatalf("expected SIGUSR1 to be printed only, got %v", p.Signals)
+		}
+	})
+}
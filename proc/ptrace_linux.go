@@ -23,6 +23,19 @@ func PtraceSingleStep(tid int) error {
 	return sys.PtraceSingleStep(tid)
 }
 
+// Returns the si_code of the signal the thread is stopped for, it is
+// positive for the signals raised by the kernel, like the SIGTRAP of a
+// single step, and not for the ones sent with kill.
+func PtraceGetSigInfoCode(tid int) (int32, error) {
+	var info [128]byte
+	_, _, err := sys.Syscall6(sys.SYS_PTRACE, sys.PTRACE_GETSIGINFO, uintptr(tid), 0, uintptr(unsafe.Pointer(&info[0])), 0, 0)
+	if err != syscall.Errno(0) {
+		return 0, err
+	}
+	// si_code follows si_signo and si_errno.
+	return *(*int32)(unsafe.Pointer(&info[8])), nil
+}
+
 func PtracePokeUser(tid int, off, addr uintptr) error {
 	_, _, err := sys.Syscall6(sys.SYS_PTRACE, sys.PTRACE_POKEUSR, uintptr(tid), uintptr(off), uintptr(addr), 0, 0)
 	if err != syscall.Errno(0) {
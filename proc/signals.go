@@ -0,0 +1,186 @@
+package proc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"syscall"
+
+	sys "golang.org/x/sys/unix"
+)
+
+// SignalPolicy says what happens when a thread of the process receives
+// a signal, like gdb's handle command.
+type SignalPolicy struct {
+	Stop  bool // Stop the process, implies Print.
+	Print bool // Report the signal.
+	Pass  bool // Deliver the signal to the process.
+}
+
+// Set changes the policy according to one of the actions of gdb's
+// handle command: stop, nostop, print, noprint, pass, nopass, ignore
+// (same as nopass) or noignore (same as pass). Stopping implies
+// printing, so stop sets Print and noprint clears Stop.
+func (p *SignalPolicy) Set(action string) error {
+	switch strings.ToLower(action) {
+	case "stop":
+		p.Stop, p.Print = true, true
+	case "nostop":
+		p.Stop = false
+	case "print":
+		p.Print = true
+	case "noprint":
+		p.Stop, p.Print = false, false
+	case "pass", "noignore":
+		p.Pass = true
+	case "nopass", "ignore":
+		p.Pass = false
+	default:
+		return fmt.Errorf("unknown action %q, must be one of stop, nostop, print, noprint, pass, nopass, ignore, noignore", action)
+	}
+	return nil
+}
+
+// Policy of the signals not listed in defaultSignalPolicies, like
+// SIGUSR1 or the real-time signals, which programs may use for their
+// own purposes.
+var defaultSignalPolicy = SignalPolicy{Print: true, Pass: true}
+
+// Signals which are part of the normal operation of most programs, the
+// Go runtime for one uses SIGURG to preempt goroutines, are passed
+// silently. Signals reporting a fault or asking the program to
+// terminate stop the process. SIGINT is not passed, as in gdb.
+var defaultSignalPolicies = map[syscall.Signal]SignalPolicy{
+	sys.SIGALRM:   {Pass: true},
+	sys.SIGCHLD:   {Pass: true},
+	sys.SIGIO:     {Pass: true},
+	sys.SIGPROF:   {Pass: true},
+	sys.SIGURG:    {Pass: true},
+	sys.SIGVTALRM: {Pass: true},
+	sys.SIGWINCH:  {Pass: true},
+	sys.SIGINT:    {Stop: true, Print: true},
+	sys.SIGHUP:    {Stop: true, Print: true, Pass: true},
+	sys.SIGQUIT:   {Stop: true, Print: true, Pass: true},
+	sys.SIGILL:    {Stop: true, Print: true, Pass: true},
+	sys.SIGABRT:   {Stop: true, Print: true, Pass: true},
+	sys.SIGBUS:    {Stop: true, Print: true, Pass: true},
+	sys.SIGFPE:    {Stop: true, Print: true, Pass: true},
+	sys.SIGSEGV:   {Stop: true, Print: true, Pass: true},
+	sys.SIGTERM:   {Stop: true, Print: true, Pass: true},
+	sys.SIGSYS:    {Stop: true, Print: true, Pass: true},
+	sys.SIGXCPU:   {Stop: true, Print: true, Pass: true},
+	sys.SIGXFSZ:   {Stop: true, Print: true, Pass: true},
+}
+
+// Signals with a policy, SIGTRAP and SIGSTOP are used by the debugger
+// and SIGKILL can not be handled.
+var signals = []syscall.Signal{
+	sys.SIGHUP, sys.SIGINT, sys.SIGQUIT, sys.SIGILL, sys.SIGABRT,
+	sys.SIGBUS, sys.SIGFPE, sys.SIGUSR1, sys.SIGSEGV, sys.SIGUSR2,
+	sys.SIGPIPE, sys.SIGALRM, sys.SIGTERM, sys.SIGCHLD, sys.SIGCONT,
+	sys.SIGTSTP, sys.SIGTTIN, sys.SIGTTOU, sys.SIGURG, sys.SIGXCPU,
+	sys.SIGXFSZ, sys.SIGVTALRM, sys.SIGPROF, sys.SIGWINCH, sys.SIGIO,
+	sys.SIGSYS,
+}
+
+var signalNames = map[syscall.Signal]string{
+	sys.SIGHUP: "SIGHUP", sys.SIGINT: "SIGINT", sys.SIGQUIT: "SIGQUIT",
+	sys.SIGILL: "SIGILL", sys.SIGTRAP: "SIGTRAP", sys.SIGABRT: "SIGABRT",
+	sys.SIGBUS: "SIGBUS", sys.SIGFPE: "SIGFPE", sys.SIGKILL: "SIGKILL",
+	sys.SIGUSR1: "SIGUSR1", sys.SIGSEGV: "SIGSEGV", sys.SIGUSR2: "SIGUSR2",
+	sys.SIGPIPE: "SIGPIPE", sys.SIGALRM: "SIGALRM", sys.SIGTERM: "SIGTERM",
+	sys.SIGCHLD: "SIGCHLD", sys.SIGCONT: "SIGCONT", sys.SIGSTOP: "SIGSTOP",
+	sys.SIGTSTP: "SIGTSTP", sys.SIGTTIN: "SIGTTIN", sys.SIGTTOU: "SIGTTOU",
+	sys.SIGURG: "SIGURG", sys.SIGXCPU: "SIGXCPU", sys.SIGXFSZ: "SIGXFSZ",
+	sys.SIGVTALRM: "SIGVTALRM", sys.SIGPROF: "SIGPROF", sys.SIGWINCH: "SIGWINCH",
+	sys.SIGIO: "SIGIO", sys.SIGSYS: "SIGSYS",
+}
+
+// SignalName returns the name of sig, like "SIGUSR1".
+func SignalName(sig syscall.Signal) string {
+	if name, ok := signalNames[sig]; ok {
+		return name
+	}
+	return fmt.Sprintf("SIG%d", int(sig))
+}
+
+// ParseSignal returns the signal with the given name or number. The
+// SIG prefix of the name is optional and case is ignored, "SIGUSR1",
+// "usr1" and "10" are the same signal on linux.
+func ParseSignal(name string) (syscall.Signal, error) {
+	if n, err := strconv.Atoi(name); err == nil {
+		return syscall.Signal(n), nil
+	}
+	upper := strings.ToUpper(name)
+	if !strings.HasPrefix(upper, "SIG") {
+		upper = "SIG" + upper
+	}
+	for sig, signame := range signalNames {
+		if signame == upper {
+			return sig, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown signal %q", name)
+}
+
+// Signals returns the signals that have a policy, in order of number.
+func Signals() []syscall.Signal {
+	return signals
+}
+
+// SignalPolicy returns what happens when the process receives sig.
+func (dbp *Process) SignalPolicy(sig syscall.Signal) SignalPolicy {
+	if p, ok := dbp.signalPolicies[sig]; ok {
+		return p
+	}
+	if p, ok := defaultSignalPolicies[sig]; ok {
+		return p
+	}
+	return defaultSignalPolicy
+}
+
+// SetSignalPolicy changes what happens when the process receives sig.
+func (dbp *Process) SetSignalPolicy(sig syscall.Signal, p SignalPolicy) error {
+	switch sig {
+	case sys.SIGTRAP, sys.SIGSTOP:
+		return fmt.Errorf("%s is used by the debugger", SignalName(sig))
+	case sys.SIGKILL:
+		return fmt.Errorf("%s can not be handled", SignalName(sig))
+	}
+	if p.Stop {
+		p.Print = true
+	}
+	if dbp.signalPolicies == nil {
+		dbp.signalPolicies = make(map[syscall.Signal]SignalPolicy)
+	}
+	dbp.signalPolicies[sig] = p
+	return nil
+}
+
+// A ReceivedSignal is a signal received by a thread of the process
+// which was printed or stopped the process, according to its policy.
+type ReceivedSignal struct {
+	ThreadID int
+	Signal   syscall.Signal
+	Stopped  bool // The signal stopped the process.
+	Passed   bool // The signal is, or will be, delivered to the process.
+}
+
+// Records the signal sig, reported by a signal-delivery-stop of thread,
+// and reports whether the process should stop. Passed signals are
+// delivered when the thread is resumed. The signals the debugger uses,
+// SIGTRAP and SIGSTOP, are never passed, nor are system call stops.
+func (thread *Thread) receiveSignal(sig syscall.Signal) bool {
+	if sig == sys.SIGTRAP || sig == sys.SIGSTOP || sig == sys.SIGTRAP|0x80 {
+		return false
+	}
+	dbp := thread.dbp
+	p := dbp.SignalPolicy(sig)
+	if p.Pass {
+		thread.pendingSignal = sig
+	}
+	if p.Print {
+		dbp.Signals = append(dbp.Signals, ReceivedSignal{ThreadID: thread.Id, Signal: sig, Stopped: p.Stop, Passed: p.Pass})
+	}
+	return p.Stop
+}
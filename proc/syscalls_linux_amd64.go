@@ -0,0 +1,368 @@
+package proc
+
+import (
+	"fmt"
+
+	sys "golang.org/x/sys/unix"
+)
+
+// Returns the number of the system call name.
+func syscallNumber(name string) (int, error) {
+	for num := range syscallNames {
+		if name != "" && syscallNames[num] == name {
+			return num, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown system call %q", name)
+}
+
+func syscallName(num int) string {
+	if num >= 0 && num < len(syscallNames) && syscallNames[num] != "" {
+		return syscallNames[num]
+	}
+	return fmt.Sprintf("syscall_%d", num)
+}
+
+// Decodes the system call thread is stopped at, thread must be in a
+// syscall-enter-stop or a syscall-exit-stop, which alternate. On entry
+// the kernel sets rax to -ENOSYS, the number of the system call is kept
+// in orig_rax. A system call can also return ENOSYS, so any other value
+// only confirms an exit, in case the entry was missed.
+func (thread *Thread) syscallStop() (*SyscallStop, error) {
+	var (
+		regs sys.PtraceRegs
+		err  error
+	)
+	thread.dbp.execPtraceFunc(func() { err = sys.PtraceGetRegs(thread.Id, &regs) })
+	if err != nil {
+		return nil, fmt.Errorf("could not read registers of thread %d %s", thread.Id, err)
+	}
+	num := int(int64(regs.Orig_rax))
+	s := &SyscallStop{
+		Number: num,
+		Name:   syscallName(num),
+		Args:   [6]uint64{regs.Rdi, regs.Rsi, regs.Rdx, regs.R10, regs.R8, regs.R9},
+	}
+	s.Exit = thread.os.inSyscall || int64(regs.Rax) != -int64(sys.ENOSYS)
+	if s.Exit {
+		s.Return = int64(regs.Rax)
+	}
+	thread.os.inSyscall = !s.Exit
+	return s, nil
+}
+
+// Names of the system calls, indexed by number.
+var syscallNames = [...]string{
+	sys.SYS_READ:                   "read",
+	sys.SYS_WRITE:                  "write",
+	sys.SYS_OPEN:                   "open",
+	sys.SYS_CLOSE:                  "close",
+	sys.SYS_STAT:                   "stat",
+	sys.SYS_FSTAT:                  "fstat",
+	sys.SYS_LSTAT:                  "lstat",
+	sys.SYS_POLL:                   "poll",
+	sys.SYS_LSEEK:                  "lseek",
+	sys.SYS_MMAP:                   "mmap",
+	sys.SYS_MPROTECT:               "mprotect",
+	sys.SYS_MUNMAP:                 "munmap",
+	sys.SYS_BRK:                    "brk",
+	sys.SYS_RT_SIGACTION:           "rt_sigaction",
+	sys.SYS_RT_SIGPROCMASK:         "rt_sigprocmask",
+	sys.SYS_RT_SIGRETURN:           "rt_sigreturn",
+	sys.SYS_IOCTL:                  "ioctl",
+	sys.SYS_PREAD64:                "pread64",
+	sys.SYS_PWRITE64:               "pwrite64",
+	sys.SYS_READV:                  "readv",
+	sys.SYS_WRITEV:                 "writev",
+	sys.SYS_ACCESS:                 "access",
+	sys.SYS_PIPE:                   "pipe",
+	sys.SYS_SELECT:                 "select",
+	sys.SYS_SCHED_YIELD:            "sched_yield",
+	sys.SYS_MREMAP:                 "mremap",
+	sys.SYS_MSYNC:                  "msync",
+	sys.SYS_MINCORE:                "mincore",
+	sys.SYS_MADVISE:                "madvise",
+	sys.SYS_SHMGET:                 "shmget",
+	sys.SYS_SHMAT:                  "shmat",
+	sys.SYS_SHMCTL:                 "shmctl",
+	sys.SYS_DUP:                    "dup",
+	sys.SYS_DUP2:                   "dup2",
+	sys.SYS_PAUSE:                  "pause",
+	sys.SYS_NANOSLEEP:              "nanosleep",
+	sys.SYS_GETITIMER:              "getitimer",
+	sys.SYS_ALARM:                  "alarm",
+	sys.SYS_SETITIMER:              "setitimer",
+	sys.SYS_GETPID:                 "getpid",
+	sys.SYS_SENDFILE:               "sendfile",
+	sys.SYS_SOCKET:                 "socket",
+	sys.SYS_CONNECT:                "connect",
+	sys.SYS_ACCEPT:                 "accept",
+	sys.SYS_SENDTO:                 "sendto",
+	sys.SYS_RECVFROM:               "recvfrom",
+	sys.SYS_SENDMSG:                "sendmsg",
+	sys.SYS_RECVMSG:                "recvmsg",
+	sys.SYS_SHUTDOWN:               "shutdown",
+	sys.SYS_BIND:                   "bind",
+	sys.SYS_LISTEN:                 "listen",
+	sys.SYS_GETSOCKNAME:            "getsockname",
+	sys.SYS_GETPEERNAME:            "getpeername",
+	sys.SYS_SOCKETPAIR:             "socketpair",
+	sys.SYS_SETSOCKOPT:             "setsockopt",
+	sys.SYS_GETSOCKOPT:             "getsockopt",
+	sys.SYS_CLONE:                  "clone",
+	sys.SYS_FORK:                   "fork",
+	sys.SYS_VFORK:                  "vfork",
+	sys.SYS_EXECVE:                 "execve",
+	sys.SYS_EXIT:                   "exit",
+	sys.SYS_WAIT4:                  "wait4",
+	sys.SYS_KILL:                   "kill",
+	sys.SYS_UNAME:                  "uname",
+	sys.SYS_SEMGET:                 "semget",
+	sys.SYS_SEMOP:                  "semop",
+	sys.SYS_SEMCTL:                 "semctl",
+	sys.SYS_SHMDT:                  "shmdt",
+	sys.SYS_MSGGET:                 "msgget",
+	sys.SYS_MSGSND:                 "msgsnd",
+	sys.SYS_MSGRCV:                 "msgrcv",
+	sys.SYS_MSGCTL:                 "msgctl",
+	sys.SYS_FCNTL:                  "fcntl",
+	sys.SYS_FLOCK:                  "flock",
+	sys.SYS_FSYNC:                  "fsync",
+	sys.SYS_FDATASYNC:              "fdatasync",
+	sys.SYS_TRUNCATE:               "truncate",
+	sys.SYS_FTRUNCATE:              "ftruncate",
+	sys.SYS_GETDENTS:               "getdents",
+	sys.SYS_GETCWD:                 "getcwd",
+	sys.SYS_CHDIR:                  "chdir",
+	sys.SYS_FCHDIR:                 "fchdir",
+	sys.SYS_RENAME:                 "rename",
+	sys.SYS_MKDIR:                  "mkdir",
+	sys.SYS_RMDIR:                  "rmdir",
+	sys.SYS_CREAT:                  "creat",
+	sys.SYS_LINK:                   "link",
+	sys.SYS_UNLINK:                 "unlink",
+	sys.SYS_SYMLINK:                "symlink",
+	sys.SYS_READLINK:               "readlink",
+	sys.SYS_CHMOD:                  "chmod",
+	sys.SYS_FCHMOD:                 "fchmod",
+	sys.SYS_CHOWN:                  "chown",
+	sys.SYS_FCHOWN:                 "fchown",
+	sys.SYS_LCHOWN:                 "lchown",
+	sys.SYS_UMASK:                  "umask",
+	sys.SYS_GETTIMEOFDAY:           "gettimeofday",
+	sys.SYS_GETRLIMIT:              "getrlimit",
+	sys.SYS_GETRUSAGE:              "getrusage",
+	sys.SYS_SYSINFO:                "sysinfo",
+	sys.SYS_TIMES:                  "times",
+	sys.SYS_PTRACE:                 "ptrace",
+	sys.SYS_GETUID:                 "getuid",
+	sys.SYS_SYSLOG:                 "syslog",
+	sys.SYS_GETGID:                 "getgid",
+	sys.SYS_SETUID:                 "setuid",
+	sys.SYS_SETGID:                 "setgid",
+	sys.SYS_GETEUID:                "geteuid",
+	sys.SYS_GETEGID:                "getegid",
+	sys.SYS_SETPGID:                "setpgid",
+	sys.SYS_GETPPID:                "getppid",
+	sys.SYS_GETPGRP:                "getpgrp",
+	sys.SYS_SETSID:                 "setsid",
+	sys.SYS_SETREUID:               "setreuid",
+	sys.SYS_SETREGID:               "setregid",
+	sys.SYS_GETGROUPS:              "getgroups",
+	sys.SYS_SETGROUPS:              "setgroups",
+	sys.SYS_SETRESUID:              "setresuid",
+	sys.SYS_GETRESUID:              "getresuid",
+	sys.SYS_SETRESGID:              "setresgid",
+	sys.SYS_GETRESGID:              "getresgid",
+	sys.SYS_GETPGID:                "getpgid",
+	sys.SYS_SETFSUID:               "setfsuid",
+	sys.SYS_SETFSGID:               "setfsgid",
+	sys.SYS_GETSID:                 "getsid",
+	sys.SYS_CAPGET:                 "capget",
+	sys.SYS_CAPSET:                 "capset",
+	sys.SYS_RT_SIGPENDING:          "rt_sigpending",
+	sys.SYS_RT_SIGTIMEDWAIT:        "rt_sigtimedwait",
+	sys.SYS_RT_SIGQUEUEINFO:        "rt_sigqueueinfo",
+	sys.SYS_RT_SIGSUSPEND:          "rt_sigsuspend",
+	sys.SYS_SIGALTSTACK:            "sigaltstack",
+	sys.SYS_UTIME:                  "utime",
+	sys.SYS_MKNOD:                  "mknod",
+	sys.SYS_USELIB:                 "uselib",
+	sys.SYS_PERSONALITY:            "personality",
+	sys.SYS_USTAT:                  "ustat",
+	sys.SYS_STATFS:                 "statfs",
+	sys.SYS_FSTATFS:                "fstatfs",
+	sys.SYS_SYSFS:                  "sysfs",
+	sys.SYS_GETPRIORITY:            "getpriority",
+	sys.SYS_SETPRIORITY:            "setpriority",
+	sys.SYS_SCHED_SETPARAM:         "sched_setparam",
+	sys.SYS_SCHED_GETPARAM:         "sched_getparam",
+	sys.SYS_SCHED_SETSCHEDULER:     "sched_setscheduler",
+	sys.SYS_SCHED_GETSCHEDULER:     "sched_getscheduler",
+	sys.SYS_SCHED_GET_PRIORITY_MAX: "sched_get_priority_max",
+	sys.SYS_SCHED_GET_PRIORITY_MIN: "sched_get_priority_min",
+	sys.SYS_SCHED_RR_GET_INTERVAL:  "sched_rr_get_interval",
+	sys.SYS_MLOCK:                  "mlock",
+	sys.SYS_MUNLOCK:                "munlock",
+	sys.SYS_MLOCKALL:               "mlockall",
+	sys.SYS_MUNLOCKALL:             "munlockall",
+	sys.SYS_VHANGUP:                "vhangup",
+	sys.SYS_MODIFY_LDT:             "modify_ldt",
+	sys.SYS_PIVOT_ROOT:             "pivot_root",
+	sys.SYS__SYSCTL:                "_sysctl",
+	sys.SYS_PRCTL:                  "prctl",
+	sys.SYS_ARCH_PRCTL:             "arch_prctl",
+	sys.SYS_ADJTIMEX:               "adjtimex",
+	sys.SYS_SETRLIMIT:              "setrlimit",
+	sys.SYS_CHROOT:                 "chroot",
+	sys.SYS_SYNC:                   "sync",
+	sys.SYS_ACCT:                   "acct",
+	sys.SYS_SETTIMEOFDAY:           "settimeofday",
+	sys.SYS_MOUNT:                  "mount",
+	sys.SYS_UMOUNT2:                "umount2",
+	sys.SYS_SWAPON:                 "swapon",
+	sys.SYS_SWAPOFF:                "swapoff",
+	sys.SYS_REBOOT:                 "reboot",
+	sys.SYS_SETHOSTNAME:            "sethostname",
+	sys.SYS_SETDOMAINNAME:          "setdomainname",
+	sys.SYS_IOPL:                   "iopl",
+	sys.SYS_IOPERM:                 "ioperm",
+	sys.SYS_CREATE_MODULE:          "create_module",
+	sys.SYS_INIT_MODULE:            "init_module",
+	sys.SYS_DELETE_MODULE:          "delete_module",
+	sys.SYS_GET_KERNEL_SYMS:        "get_kernel_syms",
+	sys.SYS_QUERY_MODULE:           "query_module",
+	sys.SYS_QUOTACTL:               "quotactl",
+	sys.SYS_NFSSERVCTL:             "nfsservctl",
+	sys.SYS_GETPMSG:                "getpmsg",
+	sys.SYS_PUTPMSG:                "putpmsg",
+	sys.SYS_AFS_SYSCALL:            "afs_syscall",
+	sys.SYS_TUXCALL:                "tuxcall",
+	sys.SYS_SECURITY:               "security",
+	sys.SYS_GETTID:                 "gettid",
+	sys.SYS_READAHEAD:              "readahead",
+	sys.SYS_SETXATTR:               "setxattr",
+	sys.SYS_LSETXATTR:              "lsetxattr",
+	sys.SYS_FSETXATTR:              "fsetxattr",
+	sys.SYS_GETXATTR:               "getxattr",
+	sys.SYS_LGETXATTR:              "lgetxattr",
+	sys.SYS_FGETXATTR:              "fgetxattr",
+	sys.SYS_LISTXATTR:              "listxattr",
+	sys.SYS_LLISTXATTR:             "llistxattr",
+	sys.SYS_FLISTXATTR:             "flistxattr",
+	sys.SYS_REMOVEXATTR:            "removexattr",
+	sys.SYS_LREMOVEXATTR:           "lremovexattr",
+	sys.SYS_FREMOVEXATTR:           "fremovexattr",
+	sys.SYS_TKILL:                  "tkill",
+	sys.SYS_TIME:                   "time",
+	sys.SYS_FUTEX:                  "futex",
+	sys.SYS_SCHED_SETAFFINITY:      "sched_setaffinity",
+	sys.SYS_SCHED_GETAFFINITY:      "sched_getaffinity",
+	sys.SYS_SET_THREAD_AREA:        "set_thread_area",
+	sys.SYS_IO_SETUP:               "io_setup",
+	sys.SYS_IO_DESTROY:             "io_destroy",
+	sys.SYS_IO_GETEVENTS:           "io_getevents",
+	sys.SYS_IO_SUBMIT:              "io_submit",
+	sys.SYS_IO_CANCEL:              "io_cancel",
+	sys.SYS_GET_THREAD_AREA:        "get_thread_area",
+	sys.SYS_LOOKUP_DCOOKIE:         "lookup_dcookie",
+	sys.SYS_EPOLL_CREATE:           "epoll_create",
+	sys.SYS_EPOLL_CTL_OLD:          "epoll_ctl_old",
+	sys.SYS_EPOLL_WAIT_OLD:         "epoll_wait_old",
+	sys.SYS_REMAP_FILE_PAGES:       "remap_file_pages",
+	sys.SYS_GETDENTS64:             "getdents64",
+	sys.SYS_SET_TID_ADDRESS:        "set_tid_address",
+	sys.SYS_RESTART_SYSCALL:        "restart_syscall",
+	sys.SYS_SEMTIMEDOP:             "semtimedop",
+	sys.SYS_FADVISE64:              "fadvise64",
+	sys.SYS_TIMER_CREATE:           "timer_create",
+	sys.SYS_TIMER_SETTIME:          "timer_settime",
+	sys.SYS_TIMER_GETTIME:          "timer_gettime",
+	sys.SYS_TIMER_GETOVERRUN:       "timer_getoverrun",
+	sys.SYS_TIMER_DELETE:           "timer_delete",
+	sys.SYS_CLOCK_SETTIME:          "clock_settime",
+	sys.SYS_CLOCK_GETTIME:          "clock_gettime",
+	sys.SYS_CLOCK_GETRES:           "clock_getres",
+	sys.SYS_CLOCK_NANOSLEEP:        "clock_nanosleep",
+	sys.SYS_EXIT_GROUP:             "exit_group",
+	sys.SYS_EPOLL_WAIT:             "epoll_wait",
+	sys.SYS_EPOLL_CTL:              "epoll_ctl",
+	sys.SYS_TGKILL:                 "tgkill",
+	sys.SYS_UTIMES:                 "utimes",
+	sys.SYS_VSERVER:                "vserver",
+	sys.SYS_MBIND:                  "mbind",
+	sys.SYS_SET_MEMPOLICY:          "set_mempolicy",
+	sys.SYS_GET_MEMPOLICY:          "get_mempolicy",
+	sys.SYS_MQ_OPEN:                "mq_open",
+	sys.SYS_MQ_UNLINK:              "mq_unlink",
+	sys.SYS_MQ_TIMEDSEND:           "mq_timedsend",
+	sys.SYS_MQ_TIMEDRECEIVE:        "mq_timedreceive",
+	sys.SYS_MQ_NOTIFY:              "mq_notify",
+	sys.SYS_MQ_GETSETATTR:          "mq_getsetattr",
+	sys.SYS_KEXEC_LOAD:             "kexec_load",
+	sys.SYS_WAITID:                 "waitid",
+	sys.SYS_ADD_KEY:                "add_key",
+	sys.SYS_REQUEST_KEY:            "request_key",
+	sys.SYS_KEYCTL:                 "keyctl",
+	sys.SYS_IOPRIO_SET:             "ioprio_set",
+	sys.SYS_IOPRIO_GET:             "ioprio_get",
+	sys.SYS_INOTIFY_INIT:           "inotify_init",
+	sys.SYS_INOTIFY_ADD_WATCH:      "inotify_add_watch",
+	sys.SYS_INOTIFY_RM_WATCH:       "inotify_rm_watch",
+	sys.SYS_MIGRATE_PAGES:          "migrate_pages",
+	sys.SYS_OPENAT:                 "openat",
+	sys.SYS_MKDIRAT:                "mkdirat",
+	sys.SYS_MKNODAT:                "mknodat",
+	sys.SYS_FCHOWNAT:               "fchownat",
+	sys.SYS_FUTIMESAT:              "futimesat",
+	sys.SYS_NEWFSTATAT:             "newfstatat",
+	sys.SYS_UNLINKAT:               "unlinkat",
+	sys.SYS_RENAMEAT:               "renameat",
+	sys.SYS_LINKAT:                 "linkat",
+	sys.SYS_SYMLINKAT:              "symlinkat",
+	sys.SYS_READLINKAT:             "readlinkat",
+	sys.SYS_FCHMODAT:               "fchmodat",
+	sys.SYS_FACCESSAT:              "faccessat",
+	sys.SYS_PSELECT6:               "pselect6",
+	sys.SYS_PPOLL:                  "ppoll",
+	sys.SYS_UNSHARE:                "unshare",
+	sys.SYS_SET_ROBUST_LIST:        "set_robust_list",
+	sys.SYS_GET_ROBUST_LIST:        "get_robust_list",
+	sys.SYS_SPLICE:                 "splice",
+	sys.SYS_TEE:                    "tee",
+	sys.SYS_SYNC_FILE_RANGE:        "sync_file_range",
+	sys.SYS_VMSPLICE:               "vmsplice",
+	sys.SYS_MOVE_PAGES:             "move_pages",
+	sys.SYS_UTIMENSAT:              "utimensat",
+	sys.SYS_EPOLL_PWAIT:            "epoll_pwait",
+	sys.SYS_SIGNALFD:               "signalfd",
+	sys.SYS_TIMERFD_CREATE:         "timerfd_create",
+	sys.SYS_EVENTFD:                "eventfd",
+	sys.SYS_FALLOCATE:              "fallocate",
+	sys.SYS_TIMERFD_SETTIME:        "timerfd_settime",
+	sys.SYS_TIMERFD_GETTIME:        "timerfd_gettime",
+	sys.SYS_ACCEPT4:                "accept4",
+	sys.SYS_SIGNALFD4:              "signalfd4",
+	sys.SYS_EVENTFD2:               "eventfd2",
+	sys.SYS_EPOLL_CREATE1:          "epoll_create1",
+	sys.SYS_DUP3:                   "dup3",
+	sys.SYS_PIPE2:                  "pipe2",
+	sys.SYS_INOTIFY_INIT1:          "inotify_init1",
+	sys.SYS_PREADV:                 "preadv",
+	sys.SYS_PWRITEV:                "pwritev",
+	sys.SYS_RT_TGSIGQUEUEINFO:      "rt_tgsigqueueinfo",
+	sys.SYS_PERF_EVENT_OPEN:        "perf_event_open",
+	sys.SYS_RECVMMSG:               "recvmmsg",
+	sys.SYS_FANOTIFY_INIT:          "fanotify_init",
+	sys.SYS_FANOTIFY_MARK:          "fanotify_mark",
+	sys.SYS_PRLIMIT64:              "prlimit64",
+	sys.SYS_NAME_TO_HANDLE_AT:      "name_to_handle_at",
+	sys.SYS_OPEN_BY_HANDLE_AT:      "open_by_handle_at",
+	sys.SYS_CLOCK_ADJTIME:          "clock_adjtime",
+	sys.SYS_SYNCFS:                 "syncfs",
+	sys.SYS_SENDMMSG:               "sendmmsg",
+	sys.SYS_SETNS:                  "setns",
+	sys.SYS_GETCPU:                 "getcpu",
+	sys.SYS_PROCESS_VM_READV:       "process_vm_readv",
+	sys.SYS_PROCESS_VM_WRITEV:      "process_vm_writev",
+}
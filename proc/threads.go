@@ -5,6 +5,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"path/filepath"
+	"syscall"
 
 	sys "golang.org/x/sys/unix"
 
@@ -21,10 +22,14 @@ type Thread struct {
 	Id                int             // Thread ID or mach port
 	Status            *sys.WaitStatus // Status returned from last wait call
 	CurrentBreakpoint *Breakpoint     // Breakpoint thread is currently stopped at
+	CurrentWatchpoint *Watchpoint     // Watchpoint thread is currently stopped at
+	CurrentSyscall    *SyscallStop    // System call thread is currently stopped at, because of a catchpoint
+	ReturnValues      []*Variable     // Values returned by the function the thread stepped out of
 
 	dbp            *Process
 	singleStepping bool
 	running        bool
+	pendingSignal  syscall.Signal // Signal to deliver when the thread is resumed.
 	os             *OSSpecificDetails
 }
 
@@ -50,7 +55,7 @@ func (thread *Thread) Continue() error {
 	}
 	// Check whether we are stopped at a breakpoint, and
 	// if so, single step over it before continuing.
-	if _, ok := thread.dbp.FindBreakpoint(pc); ok {
+	if _, ok := thread.dbp.findEnabledBreakpoint(pc); ok {
 		if err := thread.Step(); err != nil {
 			return err
 		}
@@ -76,7 +81,7 @@ func (thread *Thread) Step() (err error) {
 		return err
 	}
 
-	bp, ok := thread.dbp.FindBreakpoint(pc)
+	bp, ok := thread.dbp.findEnabledBreakpoint(pc)
 	if ok {
 		// Clear the breakpoint so that we can continue execution.
 		_, err = bp.Clear(thread)
@@ -86,12 +91,18 @@ func (thread *Thread) Step() (err error) {
 
 		// Restore breakpoint now that we have passed it.
 		defer func() {
-			err = thread.dbp.writeSoftwareBreakpoint(thread, bp.Addr)
+			werr := thread.dbp.writeSoftwareBreakpoint(thread, bp.Addr)
+			if err == nil {
+				err = werr
+			}
 		}()
 	}
 
 	err = thread.singleStep()
 	if err != nil {
+		if _, exited := err.(ProcessExitedError); exited {
+			return err
+		}
 		return fmt.Errorf("step failed: %s", err.Error())
 	}
 	return nil
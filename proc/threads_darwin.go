@@ -35,6 +35,10 @@ func (t *Thread) singleStep() error {
 	return nil
 }
 
+func (t *Thread) startStep() error {
+	return fmt.Errorf("asynchronous single step not supported on darwin")
+}
+
 func (t *Thread) resume() error {
 	t.running = true
 	// TODO(dp) set flag for ptrace stops
@@ -104,3 +108,11 @@ func (thread *Thread) readMemory(addr uintptr, size int) ([]byte, error) {
 	}
 	return buf, nil
 }
+
+func (thread *Thread) readDebugRegister(n int) (uint64, error) {
+	return 0, fmt.Errorf("watchpoints are not supported on darwin")
+}
+
+func (thread *Thread) writeDebugRegister(n int, val uint64) error {
+	return fmt.Errorf("watchpoints are not supported on darwin")
+}
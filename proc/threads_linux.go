@@ -10,6 +10,9 @@ import (
 // to be defined.
 type OSSpecificDetails struct {
 	registers sys.PtraceRegs
+	// Set between the syscall-enter-stop and the syscall-exit-stop of a
+	// system call, the two look the same otherwise.
+	inSyscall bool
 }
 
 func (t *Thread) halt() (err error) {
@@ -18,11 +21,18 @@ func (t *Thread) halt() (err error) {
 		err = fmt.Errorf("halt err %s on thread %d", err, t.Id)
 		return
 	}
-	_, _, err = wait(t.Id, t.dbp.Pid, 0)
+	_, status, err := wait(t.Id, t.dbp.Pid, 0)
 	if err != nil {
 		err = fmt.Errorf("wait err %s on thread %d", err, t.Id)
 		return
 	}
+	t.os.inSyscall = false
+	if status != nil && status.Stopped() {
+		// The thread stopped for an other signal before the SIGSTOP
+		// was delivered, keep it. The SIGSTOP will be discarded after
+		// the thread is resumed.
+		t.receiveSignal(status.StopSignal())
+	}
 	return
 }
 
@@ -33,17 +43,99 @@ func (thread *Thread) stopped() bool {
 
 func (t *Thread) resume() (err error) {
 	t.running = true
-	t.dbp.execPtraceFunc(func() { err = PtraceCont(t.Id, 0) })
+	sig := int(t.pendingSignal)
+	t.pendingSignal = 0
+	if len(t.dbp.SyscallCatchpoints) > 0 {
+		// Stop at the next system call entry or exit.
+		t.dbp.execPtraceFunc(func() { err = sys.PtraceSyscall(t.Id, sig) })
+		return
+	}
+	// The exit of a system call the thread is in won't be reported.
+	t.os.inSyscall = false
+	t.dbp.execPtraceFunc(func() { err = PtraceCont(t.Id, sig) })
 	return
 }
 
-func (t *Thread) singleStep() (err error) {
-	t.dbp.execPtraceFunc(func() { err = sys.PtraceSingleStep(t.Id) })
-	if err != nil {
+func (t *Thread) singleStep() error {
+	if err := t.startStep(); err != nil {
 		return err
 	}
-	_, _, err = wait(t.Id, t.dbp.Pid, 0)
-	return err
+	for {
+		_, status, err := wait(t.Id, t.dbp.Pid, 0)
+		if err != nil {
+			return err
+		}
+		done, err := t.stepStatus(status)
+		if err != nil || done {
+			return err
+		}
+	}
+}
+
+// Starts a single step without waiting for it to complete.
+func (t *Thread) startStep() (err error) {
+	t.running = true
+	t.os.inSyscall = false
+	t.dbp.execPtraceFunc(func() { err = sys.PtraceSingleStep(t.Id) })
+	return
+}
+
+// Handles the wait status of a thread after a single step started by
+// startStep and reports whether the step has been completed.
+func (t *Thread) stepStatus(status *sys.WaitStatus) (done bool, err error) {
+	t.running = false
+	if status == nil || status.Exited() || status.Signaled() {
+		if t.Id == t.dbp.Pid {
+			t.dbp.exited = true
+			pe := ProcessExitedError{Pid: t.dbp.Pid}
+			if status != nil {
+				pe.Status = status.ExitStatus()
+				if status.Signaled() {
+					pe.Signal = status.Signal()
+				}
+			}
+			return false, pe
+		}
+		delete(t.dbp.Threads, t.Id)
+		return true, nil
+	}
+	if status.StopSignal() == sys.SIGTRAP && status.TrapCause() == sys.PTRACE_EVENT_CLONE {
+		// The thread cloned a new thread, grab the pid and add it to
+		// our list of traced threads, the instruction hasn't been
+		// completed yet.
+		var cloned uint
+		t.dbp.execPtraceFunc(func() { cloned, err = sys.PtraceGetEventMsg(t.Id) })
+		if err != nil {
+			return false, fmt.Errorf("could not get event message: %s", err)
+		}
+		if _, err := t.dbp.addThread(int(cloned), false); err != nil && err != sys.ESRCH {
+			return false, err
+		}
+		return false, t.startStep()
+	}
+	if status.StopSignal() != sys.SIGTRAP {
+		// The thread stopped for a different signal before executing
+		// the instruction, for example the SIGSTOP sent by halt to a
+		// thread that was already stopped at a breakpoint, step again.
+		// Other signals are delivered once the thread is resumed.
+		t.receiveSignal(status.StopSignal())
+		return false, t.startStep()
+	}
+	if t.dbp.halt {
+		var code int32
+		t.dbp.execPtraceFunc(func() { code, err = PtraceGetSigInfoCode(t.Id) })
+		if err != nil {
+			return false, err
+		}
+		if code <= 0 {
+			// The SIGTRAP sent by RequestManualStop, received before the
+			// instruction was executed. Step again and let the next
+			// trapWait report the manual stop, the signal is gone.
+			t.dbp.haltThread = t
+			return false, t.startStep()
+		}
+	}
+	return true, nil
 }
 
 func (t *Thread) blocked() bool {
@@ -85,3 +177,17 @@ func (thread *Thread) readMemory(addr uintptr, size int) (data []byte, err error
 	thread.dbp.execPtraceFunc(func() { _, err = sys.PtracePeekData(thread.Id, addr, data) })
 	return
 }
+
+// Offset of u_debugreg in struct user, see <sys/user.h>.
+const debugRegistersOffset = 848
+
+func (thread *Thread) readDebugRegister(n int) (val uint64, err error) {
+	var v uintptr
+	thread.dbp.execPtraceFunc(func() { v, err = PtracePeekUser(thread.Id, debugRegistersOffset+uintptr(n*8)) })
+	return uint64(v), err
+}
+
+func (thread *Thread) writeDebugRegister(n int, val uint64) (err error) {
+	thread.dbp.execPtraceFunc(func() { err = PtracePokeUser(thread.Id, debugRegistersOffset+uintptr(n*8), uintptr(val)) })
+	return
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"go/ast"
+	"go/constant"
 	"go/parser"
 	"go/token"
 	"strconv"
@@ -40,6 +41,10 @@ type Variable struct {
 	base      uintptr
 	stride    int64
 	fieldType dwarf.Type
+
+	// Value of the results of expressions computed by the evaluator,
+	// like x+1, which are not stored in the memory of the process.
+	value constant.Value
 }
 
 // Represents a runtime M (OS thread) structure.
@@ -101,7 +106,7 @@ func newVariable(name string, addr uintptr, dwarfType dwarf.Type, thread *Thread
 		Type:      dwarfType.String(),
 	}
 
-	switch t := dwarfType.(type) {
+	switch t := resolveTypedef(dwarfType).(type) {
 	case *dwarf.StructType:
 		if strings.HasPrefix(t.StructName, "[]") {
 			err := v.loadSliceInfo(t)
@@ -150,6 +155,12 @@ func (g *G) ChanRecvBlocked() bool {
 	return g.WaitReason == ChanRecv
 }
 
+// Returns whether the goroutine is blocked on
+// a channel send operation.
+func (g *G) ChanSendBlocked() bool {
+	return g.WaitReason == ChanSend
+}
+
 // chanRecvReturnAddr returns the address of the return from a channel read.
 func (g *G) chanRecvReturnAddr(dbp *Process) (uint64, error) {
 	locs, err := dbp.stacktrace(g.PC, g.SP, 4)
@@ -293,40 +304,22 @@ func parseG(thread *Thread, gaddr uint64, deref bool) (*G, error) {
 	return g, nil
 }
 
-// Returns information for the named variable.
+// Returns information for the variable name refers to. name is a Go
+// expression, like "a.b", "a[3]", "*p" or "len(s)".
 func (scope *EvalScope) ExtractVariableInfo(name string) (*Variable, error) {
-	parts := strings.Split(name, ".")
-	varName := parts[0]
-	memberNames := parts[1:]
-
-	v, err := scope.extractVarInfo(varName)
+	expr, err := parser.ParseExpr(name)
 	if err != nil {
-		origErr := err
-		// Attempt to evaluate name as a package variable.
-		if len(memberNames) > 0 {
-			v, err = scope.packageVarAddr(name)
-		} else {
-			_, _, fn := scope.Thread.dbp.PCToLine(scope.PC)
-			if fn != nil {
-				v, err = scope.packageVarAddr(fn.PackageName() + "." + name)
-			}
-		}
-		if err != nil {
-			return nil, origErr
-		}
-		v.Name = name
-	} else {
-		for _, memberName := range memberNames {
-			v, err = v.structMember(memberName)
-			if err != nil {
-				return nil, err
-			}
-		}
+		return nil, err
+	}
+	v, err := scope.evalAST(expr)
+	if err != nil {
+		return nil, err
 	}
+	v.Name = name
 	return v, nil
 }
 
-// Returns the value of the named variable.
+// Returns the value of the expression name.
 func (scope *EvalScope) EvalVariable(name string) (*Variable, error) {
 	v, err := scope.ExtractVariableInfo(name)
 	if err != nil {
@@ -342,6 +335,9 @@ func (scope *EvalScope) SetVariable(name, value string) error {
 	if err != nil {
 		return err
 	}
+	if !v.addressable() {
+		return fmt.Errorf("can not assign to %s", name)
+	}
 	return v.setValue(value)
 }
 
@@ -390,6 +386,41 @@ func (scope *EvalScope) FunctionArguments() ([]*Variable, error) {
 	return scope.variablesByTag(dwarf.TagFormalParameter)
 }
 
+// ReturnValues returns the return values of the current function, the
+// formal parameters marked as variable parameters in the debug info.
+// Their value is only meaningful once the function has returned.
+func (scope *EvalScope) ReturnValues() ([]*Variable, error) {
+	reader := scope.DwarfReader()
+
+	_, err := reader.SeekToFunction(scope.PC)
+	if err != nil {
+		return nil, err
+	}
+
+	vars := make([]*Variable, 0)
+
+	for entry, err := reader.NextScopeVariable(); entry != nil; entry, err = reader.NextScopeVariable() {
+		if err != nil {
+			return nil, err
+		}
+
+		if entry.Tag != dwarf.TagFormalParameter {
+			continue
+		}
+		if isret, _ := entry.Val(dwarf.AttrVarParam).(bool); !isret {
+			continue
+		}
+		val, err := scope.extractVariableFromEntry(entry)
+		if err != nil {
+			// skip variables that we can't parse yet
+			continue
+		}
+		vars = append(vars, val)
+	}
+
+	return vars, nil
+}
+
 // PackageVariables returns the name, value, and type of all package variables in the application.
 func (scope *EvalScope) PackageVariables() ([]*Variable, error) {
 	reader := scope.DwarfReader()
@@ -512,6 +543,10 @@ func (v *Variable) maybeDereference() (*Variable, error) {
 
 	switch t := v.dwarfType.(type) {
 	case *dwarf.PtrType:
+		if v.value != nil {
+			ptrval, _ := constant.Uint64Val(v.value)
+			return newVariable("", uintptr(ptrval), t.Type, v.thread)
+		}
 		ptrval, err := v.thread.readUintRaw(uintptr(v.Addr), int64(v.thread.dbp.arch.PtrSize()))
 		if err != nil {
 			return nil, err
@@ -525,17 +560,124 @@ func (v *Variable) maybeDereference() (*Variable, error) {
 
 // Returns a Variable with the same address but a concrete dwarfType.
 func (v *Variable) resolveTypedefs() *Variable {
-	typ := v.dwarfType
+	r := *v
+	r.dwarfType = resolveTypedef(v.dwarfType)
+	return &r
+}
+
+func resolveTypedef(typ dwarf.Type) dwarf.Type {
 	for {
 		if tt, ok := typ.(*dwarf.TypedefType); ok {
 			typ = tt.Type
 		} else {
-			break
+			return typ
 		}
 	}
-	r := *v
-	r.dwarfType = typ
-	return &r
+}
+
+// Reports whether v is stored in the memory of the process, as opposed
+// to computed by the evaluator.
+func (v *Variable) addressable() bool {
+	return v.Addr != 0 && v.value == nil
+}
+
+func (v *Variable) isString() bool {
+	if v.value != nil && v.value.Kind() == constant.String {
+		return true
+	}
+	t, ok := resolveTypedef(v.dwarfType).(*dwarf.StructType)
+	return ok && t.StructName == "string"
+}
+
+func (v *Variable) isArrayOrSlice() bool {
+	switch t := resolveTypedef(v.dwarfType).(type) {
+	case *dwarf.ArrayType:
+		return true
+	case *dwarf.StructType:
+		return strings.HasPrefix(t.StructName, "[]")
+	}
+	return false
+}
+
+// Returns the array pointed to by v if v is a pointer to an array, which
+// can be indexed and sliced like the array itself.
+func (v *Variable) derefArrayPointer() (*Variable, error) {
+	t, ok := resolveTypedef(v.dwarfType).(*dwarf.PtrType)
+	if !ok {
+		return v, nil
+	}
+	if _, ok := resolveTypedef(t.Type).(*dwarf.ArrayType); !ok {
+		return v, nil
+	}
+	a, err := v.maybeDereference()
+	if err != nil {
+		return nil, err
+	}
+	if a.Addr == 0 {
+		return nil, fmt.Errorf("%s is nil", v.Name)
+	}
+	a.Name = v.Name
+	return a, nil
+}
+
+// Returns the length of the string v.
+func (v *Variable) stringLen() (int64, error) {
+	if v.value != nil {
+		return int64(len(constant.StringVal(v.value))), nil
+	}
+	_, strlen, err := v.thread.readStringInfo(v.Addr)
+	return strlen, err
+}
+
+// Returns the bytes of the string v between low and high.
+func (v *Variable) stringSlice(low, high int64) (string, error) {
+	if v.value != nil {
+		s := constant.StringVal(v.value)
+		if low < 0 || high > int64(len(s)) || low > high {
+			return "", fmt.Errorf("index out of range [%d:%d] with length %d", low, high, len(s))
+		}
+		return s[low:high], nil
+	}
+	addr, strlen, err := v.thread.readStringInfo(v.Addr)
+	if err != nil {
+		return "", err
+	}
+	if low < 0 || high > strlen || low > high {
+		return "", fmt.Errorf("index out of range [%d:%d] with length %d", low, high, strlen)
+	}
+	if low == high {
+		return "", nil
+	}
+	val, err := v.thread.readMemory(addr+uintptr(low), int(high-low))
+	if err != nil {
+		return "", fmt.Errorf("could not read string at %#v due to %s", addr, err)
+	}
+	return string(val), nil
+}
+
+// Formats the value of a variable computed by the evaluator like the
+// values read from memory.
+func (v *Variable) constantString() string {
+	switch v.value.Kind() {
+	case constant.String:
+		s := constant.StringVal(v.value)
+		if len(s) > maxArrayValues {
+			return s[:maxArrayValues] + fmt.Sprintf("...+%d more", len(s)-maxArrayValues)
+		}
+		return s
+	case constant.Float:
+		f, _ := constant.Float64Val(v.value)
+		bits := 64
+		if t, ok := resolveTypedef(v.dwarfType).(*dwarf.FloatType); ok {
+			bits = int(t.ByteSize * 8)
+		}
+		return strconv.FormatFloat(f, 'f', -1, bits)
+	case constant.Complex:
+		r, _ := constant.Float64Val(constant.Real(v.value))
+		i, _ := constant.Float64Val(constant.Imag(v.value))
+		return fmt.Sprintf("(%s + %si)", strconv.FormatFloat(r, 'f', -1, 64), strconv.FormatFloat(i, 'f', -1, 64))
+	}
+	return v.value.ExactString()
 }
 
 // Extracts the value of the variable at the given address.
@@ -547,8 +689,21 @@ func (v *Variable) loadValue(printStructName bool) (err error) {
 func (v *Variable) loadValueInternal(printStructName bool, recurseLevel int) (string, error) {
 	v = v.resolveTypedefs()
 
+	if v.value != nil {
+		// Computed pointers are dereferenced like the ones in memory.
+		if _, isptr := v.dwarfType.(*dwarf.PtrType); !isptr {
+			return v.constantString(), nil
+		}
+	}
+
 	switch t := v.dwarfType.(type) {
 	case *dwarf.PtrType:
+		if v.isMap() {
+			return v.loadMap(recurseLevel)
+		}
+		if v.isChan() {
+			return v.loadChan(recurseLevel)
+		}
 		ptrv, err := v.maybeDereference()
 		if err != nil {
 			return "", err
@@ -571,6 +726,8 @@ func (v *Variable) loadValueInternal(printStructName bool, recurseLevel int) (st
 			return v.thread.readString(uintptr(v.Addr))
 		case strings.HasPrefix(t.StructName, "[]"):
 			return v.loadArrayValues(recurseLevel)
+		case t.StructName == "runtime.eface" || t.StructName == "runtime.iface":
+			return v.loadInterface(recurseLevel)
 		default:
 			// Recursively call extractValue to grab
 			// the value of all the members of the struct.
@@ -657,35 +814,22 @@ func (v *Variable) setValue(value string) error {
 }
 
 func (thread *Thread) readString(addr uintptr) (string, error) {
-	// string data structure is always two ptrs in size. Addr, followed by len
-	// http://research.swtch.com/godata
-
-	// read len
-	val, err := thread.readMemory(addr+uintptr(thread.dbp.arch.PtrSize()), thread.dbp.arch.PtrSize())
+	addr, strlen64, err := thread.readStringInfo(addr)
 	if err != nil {
-		return "", fmt.Errorf("could not read string len %s", err)
-	}
-	strlen := int(binary.LittleEndian.Uint64(val))
-	if strlen < 0 {
-		return "", fmt.Errorf("invalid length: %d", strlen)
+		return "", err
 	}
+	strlen := int(strlen64)
 
 	count := strlen
 	if count > maxArrayValues {
 		count = maxArrayValues
 	}
 
-	// read addr
-	val, err = thread.readMemory(addr, thread.dbp.arch.PtrSize())
-	if err != nil {
-		return "", fmt.Errorf("could not read string pointer %s", err)
-	}
-	addr = uintptr(binary.LittleEndian.Uint64(val))
 	if addr == 0 {
 		return "", nil
 	}
 
-	val, err = thread.readMemory(addr, count)
+	val, err := thread.readMemory(addr, count)
 	if err != nil {
 		return "", fmt.Errorf("could not read string at %#v due to %s", addr, err)
 	}
@@ -699,6 +843,30 @@ func (thread *Thread) readString(addr uintptr) (string, error) {
 	return retstr, nil
 }
 
+// Reads the header of the string at addr, the address of its bytes
+// followed by its length.
+func (thread *Thread) readStringInfo(addr uintptr) (uintptr, int64, error) {
+	// string data structure is always two ptrs in size. Addr, followed by len
+	// http://research.swtch.com/godata
+
+	// read len
+	val, err := thread.readMemory(addr+uintptr(thread.dbp.arch.PtrSize()), thread.dbp.arch.PtrSize())
+	if err != nil {
+		return 0, 0, fmt.Errorf("could not read string len %s", err)
+	}
+	strlen := int64(binary.LittleEndian.Uint64(val))
+	if strlen < 0 {
+		return 0, 0, fmt.Errorf("invalid length: %d", strlen)
+	}
+
+	// read addr
+	val, err = thread.readMemory(addr, thread.dbp.arch.PtrSize())
+	if err != nil {
+		return 0, 0, fmt.Errorf("could not read string pointer %s", err)
+	}
+	return uintptr(binary.LittleEndian.Uint64(val)), strlen, nil
+}
+
 func (v *Variable) loadSliceInfo(t *dwarf.StructType) error {
 	var err error
 	for _, f := range t.Field {
@@ -781,6 +949,125 @@ func (v *Variable) loadArrayValues(recurseLevel int) (string, error) {
 	}
 }
 
+// Kind flag of runtime._type for values stored directly in the data
+// word of interfaces instead of being pointed to by it.
+const kindDirectIface = 1 << 5
+
+// Formats an interface as its static type followed by its dynamic type
+// and value, like error(*os.PathError) {Op: open, ...}.
+func (v *Variable) loadInterface(recurseLevel int) (string, error) {
+	data, err := v.interfaceValue()
+	if err != nil {
+		return "", err
+	}
+	if data == nil {
+		return fmt.Sprintf("%s nil", v.Type), nil
+	}
+	dyntype := data.Type
+
+	// The dynamic type already tells pointers apart, print the value
+	// they point to.
+	if _, isptr := resolveTypedef(data.dwarfType).(*dwarf.PtrType); isptr && !data.isMap() && !data.isChan() {
+		if data, err = data.maybeDereference(); err != nil {
+			return "", err
+		}
+		if data.Addr == 0 {
+			return fmt.Sprintf("%s(%s) nil", v.Type, dyntype), nil
+		}
+	}
+	val, err := data.loadValueInternal(false, recurseLevel)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s(%s) %s", v.Type, dyntype, val), nil
+}
+
+// Reports whether v is an interface, empty (runtime.eface) or not
+// (runtime.iface).
+func (v *Variable) isInterface() bool {
+	t, ok := resolveTypedef(v.dwarfType).(*dwarf.StructType)
+	return ok && (t.StructName == "runtime.eface" || t.StructName == "runtime.iface")
+}
+
+// Returns the value of an interface as a variable of its dynamic type,
+// or nil if the interface is nil. The Type of the variable is the name
+// of the dynamic type used by the runtime, like *os.PathError.
+func (v *Variable) interfaceValue() (*Variable, error) {
+	// Non empty interfaces point to their dynamic type through the
+	// itab of the interface and dynamic type pair.
+	var typeField *Variable
+	if tab, err := v.structMember("tab"); err == nil {
+		itab, err := tab.maybeDereference()
+		if err != nil {
+			return nil, err
+		}
+		if itab.Addr == 0 {
+			return nil, nil
+		}
+		if typeField, err = itab.structMember("_type"); err != nil {
+			return nil, err
+		}
+	} else if typeField, err = v.structMember("_type"); err != nil {
+		return nil, err
+	}
+	dataField, err := v.structMember("data")
+	if err != nil {
+		return nil, err
+	}
+	rtype, err := typeField.maybeDereference()
+	if err != nil {
+		return nil, err
+	}
+	if rtype.Addr == 0 {
+		return nil, nil
+	}
+
+	// Name and kind of the dynamic type, from its runtime._type.
+	namev, err := rtype.structMember("_string")
+	if err != nil {
+		return nil, err
+	}
+	namev, err = namev.maybeDereference()
+	if err != nil {
+		return nil, err
+	}
+	namelen, err := namev.stringLen()
+	if err != nil {
+		return nil, err
+	}
+	typename, err := namev.stringSlice(0, namelen)
+	if err != nil {
+		return nil, err
+	}
+	kindv, err := rtype.structMember("kind")
+	if err != nil {
+		return nil, err
+	}
+	kind, err := v.thread.readUintRaw(kindv.Addr, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := v.thread.dbp.findType(typename)
+	if err != nil {
+		return nil, fmt.Errorf("could not find dynamic type %s: %s", typename, err)
+	}
+	addr := dataField.Addr
+	if kind&kindDirectIface == 0 {
+		ptr, err := v.thread.readUintRaw(dataField.Addr, int64(v.thread.dbp.arch.PtrSize()))
+		if err != nil {
+			return nil, err
+		}
+		addr = uintptr(ptr)
+	}
+	data, err := newVariable("", addr, t, v.thread)
+	if err != nil {
+		return nil, err
+	}
+	data.Type = typename
+	return data, nil
+}
+
 func (v *Variable) readComplex(size int64) (string, error) {
 	var fs int64
 	switch size {
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"sort"
 	"strconv"
+	"strings"
 	"testing"
 
 	protest "github.com/derekparker/delve/proc/test"
@@ -391,3 +392,250 @@ func TestPointerSetting(t *testing.T) {
 		pval("*5")
 	})
 }
+
+func TestEvalExpression(t *testing.T) {
+	testcases := []varTest{
+		{"i1 + i2", "3", "", "int", nil},
+		{"i1 + 1", "2", "", "int", nil},
+		{"1 + 2*3", "7", "", "untyped int", nil},
+		{"7 / 2", "3", "", "untyped int", nil},
+		{"7.0 / 2", "3.5", "", "untyped float", nil},
+		{"i1 / 0", "", "", "", fmt.Errorf("integer divide by zero")},
+		{"f1 / 0", "", "", "", fmt.Errorf("division by zero")},
+		{"1.5 / 0.0", "", "", "", fmt.Errorf("division by zero")},
+		{"f1 % 2", "", "", "", fmt.Errorf("operator %% not defined on f1")},
+		{"i2 * f1", "", "", "", fmt.Errorf("mismatched types int and float64 in i2 * f1")},
+		{"u8 + 1", "0", "", "uint8", nil},
+		{"^u8", "0", "", "uint8", nil},
+		{"-i1", "-1", "", "int", nil},
+		{"i1 << 3", "8", "", "int", nil},
+		{"i1 < i2", "true", "", "untyped bool", nil},
+		{"i1 == 1 && s1 == \"hello world\"", "true", "", "untyped bool", nil},
+		{"arr[2]", "3", "", "int", nil},
+		{"arr[5]", "", "", "", fmt.Errorf("index 5 out of range [0:5]")},
+		{"sl[1]", "20", "", "int", nil},
+		{"parr[1]", "2", "", "int", nil},
+		{"fbs[1].Baz", "2", "", "int", nil},
+		{"s1[0]", "104", "", "uint8", nil},
+		{"s1[6:]", "world", "", "struct string", nil},
+		{"sl[1:3]", "[]int len: 2, cap: 3, [20,30]", "", "struct []int", nil},
+		{"arr[1:3:4]", "[]int len: 2, cap: 3, [2,3]", "", "struct []int", nil},
+		{"sl[2:5]", "", "", "", fmt.Errorf("slice bounds out of range [2:5:4] with capacity 4")},
+		{"*pi", "1", "", "int", nil},
+		{"*nilp", "", "", "", fmt.Errorf("nilp is nil")},
+		{"*i1", "", "", "", fmt.Errorf("invalid indirect of i1 (type int)")},
+		{"&i1", "*1", "", "*int", nil},
+		{"*&i1", "1", "", "int", nil},
+		{"&(i1 + 1)", "", "", "", fmt.Errorf("can not take the address of (i1 + 1)")},
+		{"pfb.Baz", "3", "", "int", nil},
+		{"(*pfb).Baz", "3", "", "int", nil},
+		{"len(s1)", "11", "", "int", nil},
+		{"len(sl) + cap(sl)", "8", "", "int", nil},
+		{"len(arr)", "5", "", "int", nil},
+		{"len(parr)", "5", "", "int", nil},
+		{"len(\"abc\")", "3", "", "int", nil},
+		{"len(i1)", "", "", "", fmt.Errorf("invalid argument i1 (type int) for len")},
+		{"float64(i1) + f1", "3.5", "", "float64", nil},
+		{"int(f1)", "2", "", "int", nil},
+		{"uint8(i1 + 300)", "45", "", "uint8", nil},
+		{"foo(1)", "", "", "", fmt.Errorf("foo is not a type, use call to call functions")},
+	}
+
+	withTestProcess("testvariables4", t, func(p *Process, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue() returned an error")
+
+		for _, tc := range testcases {
+			variable, err := evalVariable(p, tc.name)
+			if tc.err == nil {
+				assertNoError(err, t, fmt.Sprintf("EvalVariable(%s)", tc.name))
+				assertVariable(t, variable, tc)
+			} else {
+				if err == nil {
+					t.Fatalf("Expected error %s evaluating %s, got %s", tc.err, tc.name, variable.Value)
+				}
+				if tc.err.Error() != err.Error() {
+					t.Fatalf("Unexpected error. Expected %s got %s", tc.err.Error(), err.Error())
+				}
+			}
+		}
+
+		// Conversions of addresses to pointers.
+		scope, err := p.CurrentThread.Scope()
+		assertNoError(err, t, "Scope()")
+		fb, err := scope.ExtractVariableInfo("fb")
+		assertNoError(err, t, "ExtractVariableInfo()")
+		expr := fmt.Sprintf("(*main.FooBar)(%#x).Bur", fb.Addr)
+		variable, err := evalVariable(p, expr)
+		assertNoError(err, t, "EvalVariable()")
+		assertVariable(t, variable, varTest{name: expr, value: "three", varType: "struct string"})
+
+		// Elements are stored in memory and can be set, computed values can't.
+		assertNoError(setVariable(p, "arr[1]", "7"), t, "SetVariable()")
+		variable, err = evalVariable(p, "arr")
+		assertNoError(err, t, "EvalVariable()")
+		assertVariable(t, variable, varTest{name: "arr", value: "[5]int [1,7,3,4,5]", varType: "[5]int"})
+		if err := setVariable(p, "i1 + 1", "3"); err == nil || err.Error() != "can not assign to i1 + 1" {
+			t.Fatalf("Unexpected error setting i1 + 1: %v", err)
+		}
+	})
+}
+
+func TestMapEvaluation(t *testing.T) {
+	testcases := []varTest{
+		{"len(m1)", "2", "", "int", nil},
+		{"m1[\"two\"]", "2", "", "int", nil},
+		{"m1[\"three\"]", "", "", "", fmt.Errorf("key \"three\" not found in m1")},
+		{"m2[1].Bur", "a", "", "struct string", nil},
+		{"m3[\"a\"][3]", "3", "", "int", nil},
+		{"len(mbig)", "100", "", "int", nil},
+		{"mbig[42]", "84", "", "int", nil},
+		{"mnil", "map[string]int nil", "", "map[string]int", nil},
+		{"len(mnil)", "0", "", "int", nil},
+	}
+
+	withTestProcess("testvariables4", t, func(p *Process, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue() returned an error")
+
+		for _, tc := range testcases {
+			variable, err := evalVariable(p, tc.name)
+			if tc.err == nil {
+				assertNoError(err, t, fmt.Sprintf("EvalVariable(%s)", tc.name))
+				assertVariable(t, variable, tc)
+			} else {
+				if err == nil {
+					t.Fatalf("Expected error %s evaluating %s, got %s", tc.err, tc.name, variable.Value)
+				}
+				if tc.err.Error() != err.Error() {
+					t.Fatalf("Unexpected error. Expected %s got %s", tc.err.Error(), err.Error())
+				}
+			}
+		}
+
+		// The order of the entries depends on the hash function.
+		variable, err := evalVariable(p, "m1")
+		assertNoError(err, t, "EvalVariable()")
+		if variable.Value != "map[string]int len: 2, [one: 1, two: 2]" && variable.Value != "map[string]int len: 2, [two: 2, one: 1]" {
+			t.Fatalf("Wrong value of m1: %s", variable.Value)
+		}
+
+		// Every entry is seen once, whether it is in the old buckets or in
+		// the new ones.
+		assertNoError(p.SetMaxMapEntries(200), t, "SetMaxMapEntries()")
+		variable, err = evalVariable(p, "mbig")
+		assertNoError(err, t, "EvalVariable()")
+		entries := strings.Split(strings.TrimSuffix(strings.SplitN(variable.Value, "[", 2)[1], "]"), ", ")
+		sort.Strings(entries)
+		if len(entries) != 100 {
+			t.Fatalf("Wrong number of entries in %s", variable.Value)
+		}
+		for i := 0; i < 100; i++ {
+			entry := fmt.Sprintf("%d: %d", i, i*2)
+			if j := sort.SearchStrings(entries, entry); j >= len(entries) || entries[j] != entry {
+				t.Fatalf("Missing entry %s in %s", entry, variable.Value)
+			}
+		}
+
+		assertNoError(p.SetMaxMapEntries(10), t, "SetMaxMapEntries()")
+		variable, err = evalVariable(p, "mbig")
+		assertNoError(err, t, "EvalVariable()")
+		if !strings.HasSuffix(variable.Value, ", ...+90 more]") {
+			t.Fatalf("Wrong value of mbig with 10 max entries: %s", variable.Value)
+		}
+
+		assertNoError(setVariable(p, "m1[\"one\"]", "5"), t, "SetVariable()")
+		variable, err = evalVariable(p, "m1[\"one\"]")
+		assertNoError(err, t, "EvalVariable()")
+		assertVariable(t, variable, varTest{name: "m1[\"one\"]", value: "5", varType: "int"})
+	})
+}
+
+func TestInterfaceEvaluation(t *testing.T) {
+	testcases := []varTest{
+		{"e1", "error(*os.PathError) {Op: open, Path: /nonexistent, Err: error nil}", "", "error", nil},
+		{"enil", "error nil", "", "error", nil},
+		{"if1", "interface {}(main.FooBar) {Baz: 3, Bur: three}", "", "interface {}", nil},
+		{"if2", "interface {}(int) 5", "", "interface {}", nil},
+		{"if3", "interface {}(*main.FooBar) {Baz: 3, Bur: three}", "", "interface {}", nil},
+		{"e1.(*os.PathError).Op", "open", "", "struct string", nil},
+		{"if1.(FooBar).Baz", "3", "", "int", nil},
+		{"if2.(int)", "5", "", "int", nil},
+		{"if3.(*FooBar).Bur", "three", "", "struct string", nil},
+		{"if1.(*FooBar)", "", "", "", fmt.Errorf("interface conversion: interface {} is main.FooBar, not *main.FooBar")},
+		{"enil.(*os.PathError)", "", "", "", fmt.Errorf("interface conversion: interface is nil, not *os.PathError")},
+		{"i1.(int)", "", "", "", fmt.Errorf("invalid type assertion: i1.(int) (non-interface type int on left)")},
+	}
+
+	withTestProcess("testvariables4", t, func(p *Process, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue() returned an error")
+
+		for _, tc := range testcases {
+			variable, err := evalVariable(p, tc.name)
+			if tc.err == nil {
+				assertNoError(err, t, fmt.Sprintf("EvalVariable(%s)", tc.name))
+				assertVariable(t, variable, tc)
+			} else {
+				if err == nil {
+					t.Fatalf("Expected error %s evaluating %s, got %s", tc.err, tc.name, variable.Value)
+				}
+				if tc.err.Error() != err.Error() {
+					t.Fatalf("Unexpected error. Expected %s got %s", tc.err.Error(), err.Error())
+				}
+			}
+		}
+	})
+}
+
+func TestChanEvaluation(t *testing.T) {
+	testcases := []varTest{
+		{"ch1", "chan int len: 3, cap: 4, closed: false, [3,4,5], recvq: [], sendq: []", "", "chan int", nil},
+		{"chc", "chan int len: 1, cap: 2, closed: true, [3], recvq: [], sendq: []", "", "chan int", nil},
+		{"chn", "chan int nil", "", "chan int", nil},
+		{"len(ch1)", "3", "", "int", nil},
+		{"cap(ch1)", "4", "", "int", nil},
+		{"len(chn)", "0", "", "int", nil},
+	}
+
+	withTestProcess("testvariables4", t, func(p *Process, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue() returned an error")
+
+		for _, tc := range testcases {
+			variable, err := evalVariable(p, tc.name)
+			assertNoError(err, t, fmt.Sprintf("EvalVariable(%s)", tc.name))
+			assertVariable(t, variable, tc)
+		}
+
+		gs, err := p.GoroutinesInfo()
+		assertNoError(err, t, "GoroutinesInfo()")
+		goroutines := make(map[string]*G)
+		for _, g := range gs {
+			goroutines[strconv.Itoa(g.Id)] = g
+		}
+
+		// The goroutines parked on ch2 and ch3 are listed in their wait
+		// queues.
+		for _, tc := range []struct {
+			name, queue string
+			n           int
+			blocked     func(g *G) bool
+		}{
+			{"ch2", "sendq", 2, (*G).ChanSendBlocked},
+			{"ch3", "recvq", 1, (*G).ChanRecvBlocked},
+		} {
+			variable, err := evalVariable(p, tc.name)
+			assertNoError(err, t, fmt.Sprintf("EvalVariable(%s)", tc.name))
+			i := strings.Index(variable.Value, tc.queue+": [")
+			if i < 0 {
+				t.Fatalf("No %s in %s", tc.queue, variable.Value)
+			}
+			ids := strings.Fields(strings.SplitN(variable.Value[i+len(tc.queue)+3:], "]", 2)[0])
+			if len(ids) != tc.n {
+				t.Fatalf("Wrong number of goroutines in %s of %s: %s", tc.queue, tc.name, variable.Value)
+			}
+			for _, id := range ids {
+				if g, ok := goroutines[id]; !ok || !tc.blocked(g) {
+					t.Fatalf("Goroutine %s in %s of %s is not blocked on it", id, tc.queue, tc.name)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,469 @@
+package proc
+
+import (
+	"bytes"
+	"debug/dwarf"
+	"fmt"
+	"strings"
+
+	sys "golang.org/x/sys/unix"
+)
+
+// WatchType specifies which kind of memory access triggers a watchpoint.
+type WatchType uint8
+
+const (
+	WatchWrite     WatchType = 1 << iota // Stop when the watched memory is written.
+	WatchRead                            // Stop when the watched memory is read.
+	WatchReadWrite = WatchRead | WatchWrite
+)
+
+func (wtype WatchType) String() string {
+	switch wtype {
+	case WatchWrite:
+		return "write"
+	case WatchRead:
+		return "read"
+	case WatchReadWrite:
+		return "rw"
+	}
+	return "unknown"
+}
+
+// Represents a watchpoint, stops the process when the memory
+// of the watched expression is accessed. Watchpoints are
+// implemented using the debug registers DR0-DR3, the same
+// watchpoint is programmed on every thread of the process.
+// When no debug register is available, or the watched memory
+// is too large, a software watchpoint is used instead: the
+// process is single stepped and the watched memory is compared
+// with a snapshot after every instruction.
+type Watchpoint struct {
+	ID       int       // Monotonically increasing ID, shared with breakpoints.
+	Expr     string    // Expression being watched.
+	Addr     uint64    // Address of the watched memory.
+	Size     int       // Size of the watched memory.
+	Type     WatchType // Kind of access that triggers the watchpoint.
+	Software bool      // Software watchpoint, very slow.
+
+	OldValue      string   // Value of Expr before the last hit.
+	Value         string   // Value of Expr after the last hit.
+	ChangedFields []string // Fields, or array elements, of Expr changed by the last hit.
+	TotalHitCount uint64   // Number of times the watchpoint has been hit.
+
+	reg      int       // Debug register used by the watchpoint, -1 for software watchpoints.
+	data     []byte    // Contents of the watched memory after the last hit.
+	variable *Variable // Variable used to format the watched value.
+	// Expr with its variable qualified by its package, empty if Expr
+	// does not start with a package variable.
+	pkgExpr string
+}
+
+func (wp *Watchpoint) String() string {
+	if wp.Software {
+		return fmt.Sprintf("Software watchpoint %d on %s (%s) at %#v", wp.ID, wp.Expr, wp.Type, wp.Addr)
+	}
+	return fmt.Sprintf("Watchpoint %d on %s (%s) at %#v", wp.ID, wp.Expr, wp.Type, wp.Addr)
+}
+
+// Bits of DR6 reporting which of DR0-DR3 caused a debug exception.
+const dr6TriggerMask = 0xf
+
+// Returns the mask of the DR7 bits controlling debug register reg.
+func dr7Mask(reg int) uint64 {
+	return (0x3 << uint(reg*2)) | (0xf << uint(16+reg*4))
+}
+
+// Returns the DR7 bits that enable debug register reg as a
+// watchpoint of the given size and type.
+func dr7Bits(reg, size int, wtype WatchType) uint64 {
+	// The CPU can't trap on reads only, read watchpoints use
+	// read/write breakpoints and ignore the hits that change
+	// the value, see Watchpoint.processHit.
+	var rw uint64 = 0x1
+	if wtype&WatchRead != 0 {
+		rw = 0x3
+	}
+	var length uint64
+	switch size {
+	case 2:
+		length = 0x1
+	case 4:
+		length = 0x3
+	case 8:
+		length = 0x2
+	}
+	return (1 << uint(reg*2)) | ((rw | length<<2) << uint(16+reg*4))
+}
+
+// Sets a watchpoint on the memory of expr, evaluated in scope.
+// Falls back to a software watchpoint if a hardware watchpoint
+// can't be used.
+func (dbp *Process) SetWatchpoint(scope *EvalScope, expr string, wtype WatchType) (*Watchpoint, error) {
+	v, err := scope.ExtractVariableInfo(expr)
+	if err != nil {
+		return nil, err
+	}
+	if !v.addressable() {
+		return nil, fmt.Errorf("can not watch %s: not stored in memory", expr)
+	}
+	size := v.dwarfType.Size()
+	if size <= 0 {
+		return nil, fmt.Errorf("can not watch %s: size %d not supported", expr, size)
+	}
+
+	wp := &Watchpoint{
+		Expr:     expr,
+		Addr:     uint64(v.Addr),
+		Size:     int(size),
+		Type:     wtype,
+		reg:      -1,
+		variable: v,
+		pkgExpr:  scope.packageExpr(expr),
+	}
+	if err := wp.load(scope.Thread); err != nil {
+		return nil, err
+	}
+	wp.OldValue = wp.Value
+
+	switch size {
+	case 1, 2, 4, 8:
+		if wp.Addr%uint64(size) != 0 {
+			break
+		}
+		for i, used := range dbp.arch.HardwareBreakpointUsage() {
+			if !used {
+				wp.reg = i
+				break
+			}
+		}
+	}
+
+	if wp.reg < 0 {
+		// Software watchpoints can only see the memory changing.
+		if wtype&WatchRead != 0 {
+			return nil, fmt.Errorf("can not watch reads of %s: needs a free debug register and a size of 1, 2, 4 or 8 bytes", expr)
+		}
+		wp.Software = true
+	} else {
+		for _, th := range dbp.Threads {
+			if err := th.setWatchpoint(wp); err != nil {
+				for _, th := range dbp.Threads {
+					th.clearWatchpoint(wp)
+				}
+				return nil, fmt.Errorf("could not set watchpoint on thread %d: %s", th.Id, err)
+			}
+		}
+		dbp.arch.SetHardwareBreakpointUsage(wp.reg, true)
+	}
+
+	dbp.breakpointIDCounter++
+	wp.ID = dbp.breakpointIDCounter
+	dbp.Watchpoints[wp.ID] = wp
+	return wp, nil
+}
+
+// RestoreWatchpoint sets a watchpoint with the ID, expression and type of
+// old, a watchpoint of a previous process. Only watchpoints on package
+// variables can be restored, the expression is evaluated again in the
+// new process.
+func (dbp *Process) RestoreWatchpoint(old *Watchpoint) (*Watchpoint, error) {
+	if old.pkgExpr == "" {
+		return nil, fmt.Errorf("%s is not a package variable", old.Expr)
+	}
+	if _, ok := dbp.Watchpoints[old.ID]; ok {
+		return nil, fmt.Errorf("watchpoint %d already exists", old.ID)
+	}
+	counter := dbp.breakpointIDCounter
+	scope := &EvalScope{Thread: dbp.CurrentThread, PC: 0, CFA: 0}
+	wp, err := dbp.SetWatchpoint(scope, old.pkgExpr, old.Type)
+	if err != nil {
+		return nil, err
+	}
+	delete(dbp.Watchpoints, wp.ID)
+	wp.ID = old.ID
+	wp.Expr = old.Expr
+	wp.variable.Name = old.Expr
+	dbp.Watchpoints[wp.ID] = wp
+	dbp.breakpointIDCounter = counter
+	if wp.ID > counter {
+		dbp.breakpointIDCounter = wp.ID
+	}
+	return wp, nil
+}
+
+// Clears the watchpoint with the given ID.
+func (dbp *Process) ClearWatchpoint(id int) (*Watchpoint, error) {
+	wp, ok := dbp.Watchpoints[id]
+	if !ok {
+		return nil, NoWatchpointError{id: id}
+	}
+	if !wp.Software {
+		for _, th := range dbp.Threads {
+			if err := th.clearWatchpoint(wp); err != nil {
+				return nil, fmt.Errorf("could not clear watchpoint on thread %d: %s", th.Id, err)
+			}
+		}
+		dbp.arch.SetHardwareBreakpointUsage(wp.reg, false)
+	}
+	delete(dbp.Watchpoints, id)
+	return wp, nil
+}
+
+// Returns the watchpoint the current thread is stopped at, if any.
+func (dbp *Process) CurrentWatchpoint() *Watchpoint {
+	return dbp.CurrentThread.CurrentWatchpoint
+}
+
+// Reads the current contents and value of the watched memory.
+func (wp *Watchpoint) load(thread *Thread) error {
+	data, err := thread.readMemory(uintptr(wp.Addr), wp.Size)
+	if err != nil {
+		return err
+	}
+	v := *wp.variable
+	v.thread = thread
+	if err := v.loadValue(true); err != nil {
+		return err
+	}
+	wp.data = data
+	wp.Value = v.Value
+	return nil
+}
+
+// Records a hit of the watchpoint by thread and reports whether
+// the process should stop there.
+func (wp *Watchpoint) processHit(thread *Thread) (bool, error) {
+	olddata, oldvalue := wp.data, wp.Value
+	if err := wp.load(thread); err != nil {
+		return true, err
+	}
+	if wp.Type == WatchRead && !bytes.Equal(olddata, wp.data) {
+		// The memory was written, not read.
+		return false, nil
+	}
+	wp.OldValue = oldvalue
+	wp.ChangedFields = wp.variable.changedFields(olddata, wp.data)
+	wp.TotalHitCount++
+	return true, nil
+}
+
+// Returns the names of the fields, or the indexes of the elements,
+// of v whose memory differs between olddata and newdata.
+func (v *Variable) changedFields(olddata, newdata []byte) []string {
+	differs := func(off, size int64) bool {
+		if off < 0 || size < 0 || off+size > int64(len(olddata)) || off+size > int64(len(newdata)) {
+			return false
+		}
+		return !bytes.Equal(olddata[off:off+size], newdata[off:off+size])
+	}
+
+	var changed []string
+	switch t := v.resolveTypedefs().dwarfType.(type) {
+	case *dwarf.StructType:
+		if t.StructName == "string" || strings.HasPrefix(t.StructName, "[]") {
+			return nil
+		}
+		for _, f := range t.Field {
+			if differs(f.ByteOffset, f.Type.Size()) {
+				changed = append(changed, f.Name)
+			}
+		}
+	case *dwarf.ArrayType:
+		size := t.Type.Size()
+		for i := int64(0); i < t.Count; i++ {
+			if differs(i*size, size) {
+				changed = append(changed, fmt.Sprintf("[%d]", i))
+			}
+		}
+	}
+	return changed
+}
+
+func (dbp *Process) hasSoftwareWatchpoints() bool {
+	for _, wp := range dbp.Watchpoints {
+		if wp.Software {
+			return true
+		}
+	}
+	return false
+}
+
+// Resumes the process single stepping all of its threads, one
+// instruction at a time, until a breakpoint or a watchpoint is hit.
+// Threads are stepped concurrently, a thread blocked in a system
+// call completes its step when the system call returns.
+func (dbp *Process) continueSoftwareWatch() (err error) {
+	var stopthread *Thread
+	defer func() {
+		if _, exited := err.(ProcessExitedError); exited {
+			return
+		}
+		// Stop the threads that are still in the middle of a step.
+		if herr := dbp.Halt(); err == nil {
+			err = herr
+		}
+		if stopthread != nil {
+			dbp.SwitchThread(stopthread.Id)
+		}
+	}()
+
+	for {
+		if dbp.halt {
+			dbp.halt = false
+			return nil
+		}
+		for _, th := range dbp.Threads {
+			if th.running {
+				continue
+			}
+			// Errors here usually mean that the thread has been killed
+			// because the process is exiting, waitStep will report it.
+			pc, err := th.PC()
+			if err != nil {
+				continue
+			}
+			if _, ok := dbp.findEnabledBreakpoint(pc); !ok {
+				if err := th.startStep(); err != nil && err != sys.ESRCH {
+					return err
+				}
+				continue
+			}
+			// Stepping over a breakpoint clears it temporarily, no other
+			// thread can go through it since they are all at a different
+			// address and execute a single instruction.
+			if err := th.Step(); err != nil {
+				return err
+			}
+			if _, ok := dbp.Threads[th.Id]; !ok {
+				continue
+			}
+			if stop, err := dbp.checkStep(th); stop || err != nil {
+				stopthread = th
+				return err
+			}
+		}
+
+		th, done, err := dbp.waitStep()
+		if err != nil {
+			return err
+		}
+		if !done {
+			continue
+		}
+		if _, ok := dbp.Threads[th.Id]; !ok {
+			// The thread exited.
+			continue
+		}
+		stop, err := dbp.checkStep(th)
+		if err != nil {
+			// As above, the thread is most likely being killed,
+			// its exit will be reported by the next call to waitStep.
+			continue
+		}
+		if stop {
+			stopthread = th
+			return nil
+		}
+	}
+}
+
+// Checks whether the instruction just executed by thread
+// triggered a watchpoint, or if thread reached a breakpoint,
+// and reports whether the process should stop.
+func (dbp *Process) checkStep(thread *Thread) (bool, error) {
+	if wp, err := thread.watchpointHit(); err != nil || wp != nil {
+		if err != nil {
+			return true, err
+		}
+		if stop, err := wp.processHit(thread); stop || err != nil {
+			thread.CurrentWatchpoint = wp
+			return true, err
+		}
+	}
+
+	for _, wp := range dbp.Watchpoints {
+		if !wp.Software {
+			continue
+		}
+		data, err := thread.readMemory(uintptr(wp.Addr), wp.Size)
+		if err != nil {
+			return true, err
+		}
+		if !bytes.Equal(data, wp.data) {
+			thread.CurrentWatchpoint = wp
+			return wp.processHit(thread)
+		}
+	}
+
+	pc, err := thread.PC()
+	if err != nil {
+		return true, err
+	}
+	if bp, ok := dbp.Breakpoints[pc]; ok && bp.Enabled && !bp.Temp {
+		thread.CurrentBreakpoint = bp
+		stop, err := bp.processHit(thread)
+		if !stop {
+			thread.CurrentBreakpoint = nil
+		}
+		return stop, err
+	}
+	return false, nil
+}
+
+// Programs the debug registers of thread for wp.
+func (thread *Thread) setWatchpoint(wp *Watchpoint) error {
+	if err := thread.writeDebugRegister(wp.reg, wp.Addr); err != nil {
+		return err
+	}
+	dr7, err := thread.readDebugRegister(7)
+	if err != nil {
+		return err
+	}
+	dr7 = dr7&^dr7Mask(wp.reg) | dr7Bits(wp.reg, wp.Size, wp.Type)
+	return thread.writeDebugRegister(7, dr7)
+}
+
+// Disables the debug register used by wp on thread.
+func (thread *Thread) clearWatchpoint(wp *Watchpoint) error {
+	dr7, err := thread.readDebugRegister(7)
+	if err != nil {
+		return err
+	}
+	return thread.writeDebugRegister(7, dr7&^dr7Mask(wp.reg))
+}
+
+// Returns the watchpoint that caused the last debug exception
+// on thread, or nil if the exception wasn't caused by a watchpoint.
+func (thread *Thread) watchpointHit() (*Watchpoint, error) {
+	if len(thread.dbp.Watchpoints) == 0 {
+		return nil, nil
+	}
+	dr6, err := thread.readDebugRegister(6)
+	if err != nil {
+		return nil, err
+	}
+	if dr6&dr6TriggerMask == 0 {
+		return nil, nil
+	}
+	// The CPU never clears DR6, we have to do it
+	// or the next exception will be misreported.
+	if err := thread.writeDebugRegister(6, 0); err != nil {
+		return nil, err
+	}
+	for _, wp := range thread.dbp.Watchpoints {
+		if !wp.Software && dr6&(1<<uint(wp.reg)) != 0 {
+			return wp, nil
+		}
+	}
+	return nil, nil
+}
+
+// Error thrown when trying to clear a watchpoint that does not exist.
+type NoWatchpointError struct {
+	id int
+}
+
+func (nwp NoWatchpointError) Error() string {
+	return fmt.Sprintf("no watchpoint with id %d", nwp.id)
+}
@@ -1,15 +1,21 @@
 package api
 
 import (
+	"bytes"
 	"debug/gosym"
+	"go/printer"
+	"go/token"
+	"strconv"
+	"syscall"
 
 	"github.com/derekparker/delve/proc"
 )
 
 // convertBreakpoint converts an internal breakpoint to an API Breakpoint.
 func ConvertBreakpoint(bp *proc.Breakpoint) *Breakpoint {
-	return &Breakpoint{
+	b := &Breakpoint{
 		ID:           bp.ID,
+		Name:         bp.Name,
 		FunctionName: bp.FunctionName,
 		File:         bp.File,
 		Line:         bp.Line,
@@ -18,6 +24,89 @@ func ConvertBreakpoint(bp *proc.Breakpoint) *Breakpoint {
 		Stacktrace:   bp.Stacktrace,
 		Goroutine:    bp.Goroutine,
 		Variables:    bp.Variables,
+		Commands:     bp.Commands,
+		LogMessage:   bp.LogMessage,
+
+		TotalHitCount: bp.TotalHitCount,
+		IgnoreCount:   bp.IgnoreCount,
+		StopEvery:     bp.StopEvery,
+		Disabled:      !bp.Enabled,
+		GoroutineID:   bp.GoroutineID,
+	}
+	if bp.CreatedAt != nil {
+		b.CreatedAt = bp.CreatedAt.Spec
+	}
+	b.HitCount = map[string]uint64{}
+	for gid, count := range bp.HitCount {
+		b.HitCount[strconv.Itoa(gid)] = count
+	}
+	if bp.Cond != nil {
+		var buf bytes.Buffer
+		printer.Fprint(&buf, token.NewFileSet(), bp.Cond)
+		b.Cond = buf.String()
+	}
+	return b
+}
+
+// ConvertWatchpoint converts an internal watchpoint to an API Watchpoint.
+func ConvertWatchpoint(wp *proc.Watchpoint) *Watchpoint {
+	return &Watchpoint{
+		ID:            wp.ID,
+		Expr:          wp.Expr,
+		Addr:          wp.Addr,
+		Size:          wp.Size,
+		Type:          wp.Type.String(),
+		Software:      wp.Software,
+		OldValue:      wp.OldValue,
+		Value:         wp.Value,
+		ChangedFields: wp.ChangedFields,
+		TotalHitCount: wp.TotalHitCount,
+	}
+}
+
+// ConvertSyscallCatchpoint converts an internal syscall catchpoint to an
+// API SyscallCatchpoint.
+func ConvertSyscallCatchpoint(cp *proc.SyscallCatchpoint) *SyscallCatchpoint {
+	return &SyscallCatchpoint{
+		ID:            cp.ID,
+		Syscalls:      cp.Syscalls,
+		TotalHitCount: cp.TotalHitCount,
+	}
+}
+
+// ConvertSyscallStop converts an internal syscall stop to an API
+// SyscallStop, without its stack trace.
+func ConvertSyscallStop(s *proc.SyscallStop) *SyscallStop {
+	return &SyscallStop{
+		Catchpoint: s.Catchpoint.ID,
+		Number:     s.Number,
+		Name:       s.Name,
+		Args:       s.Args[:],
+		Exit:       s.Exit,
+		Return:     s.Return,
+	}
+}
+
+// ConvertSignal converts a signal received by the process to an API
+// Signal.
+func ConvertSignal(s proc.ReceivedSignal) Signal {
+	return Signal{
+		ThreadID: s.ThreadID,
+		Number:   int(s.Signal),
+		Name:     proc.SignalName(s.Signal),
+		Stopped:  s.Stopped,
+		Passed:   s.Passed,
+	}
+}
+
+// ConvertSignalPolicy converts the policy of sig to an API SignalPolicy.
+func ConvertSignalPolicy(sig syscall.Signal, p proc.SignalPolicy) SignalPolicy {
+	return SignalPolicy{
+		Number: int(sig),
+		Name:   proc.SignalName(sig),
+		Stop:   p.Stop,
+		Print:  p.Print,
+		Pass:   p.Pass,
 	}
 }
 
@@ -38,12 +127,18 @@ func ConvertThread(th *proc.Thread) *Thread {
 		function = ConvertFunction(loc.Fn)
 	}
 
+	var retVals []Variable
+	for _, v := range th.ReturnValues {
+		retVals = append(retVals, ConvertVar(v))
+	}
+
 	return &Thread{
-		ID:       th.Id,
-		PC:       pc,
-		File:     file,
-		Line:     line,
-		Function: function,
+		ID:           th.Id,
+		PC:           pc,
+		File:         file,
+		Line:         line,
+		Function:     function,
+		ReturnValues: retVals,
 	}
 }
 
@@ -88,3 +183,8 @@ func ConvertLocation(loc proc.Location) Location {
 		Function: ConvertFunction(loc.Fn),
 	}
 }
+
+// ConvertCheckpoint converts a proc.Checkpoint to an api.Checkpoint.
+func ConvertCheckpoint(cp *proc.Checkpoint) Checkpoint {
+	return Checkpoint{ID: cp.ID, PC: cp.PC, Where: cp.Where}
+}
@@ -2,9 +2,17 @@ package api
 
 // DebuggerState represents the current context of the debugger.
 type DebuggerState struct {
+	// Pid is the pid of the current process.
+	Pid int `json:"pid"`
 	// Breakpoint is the current breakpoint at which the debugged process is
 	// suspended, and may be empty if the process is not suspended.
 	Breakpoint *Breakpoint `json:"breakPoint,omitempty"`
+	// Watchpoint is the watchpoint that caused the debugged process to be
+	// suspended, may be empty.
+	Watchpoint *Watchpoint `json:"watchPoint,omitempty"`
+	// Syscall is the system call the current thread is stopped at because
+	// of a syscall catchpoint, may be empty.
+	Syscall *SyscallStop `json:"syscall,omitempty"`
 	// CurrentThread is the currently selected debugger thread.
 	CurrentThread *Thread `json:"currentThread,omitempty"`
 	// SelectedGoroutine is the currently selected goroutine
@@ -14,6 +22,18 @@ type DebuggerState struct {
 	// Exited indicates whether the debugged process has exited.
 	Exited     bool `json:"exited"`
 	ExitStatus int  `json:"exitStatus"`
+	// ExitSignal is the name of the signal that killed the process, if any.
+	ExitSignal string `json:"exitSignal,omitempty"`
+	// Signals are the signals received by the process since it was last
+	// resumed, whose policy is to print them or to stop.
+	Signals []Signal `json:"signals,omitempty"`
+	// Forked are the pids of the children forked by the process since it
+	// was last resumed, when following both parent and child. The
+	// children are stopped.
+	Forked []int `json:"forked,omitempty"`
+	// ExecPath is the program executed by the process, if it stopped
+	// because of an exec.
+	ExecPath string `json:"execPath,omitempty"`
 
 	// Filled by RPCClient.Continue, indicates an error
 	Err error `json:"-"`
@@ -24,6 +44,9 @@ type DebuggerState struct {
 type Breakpoint struct {
 	// ID is a unique identifier for the breakpoint.
 	ID int `json:"id"`
+	// Name is set for breakpoints created by delve itself, like the ones
+	// stopping on unrecovered panics.
+	Name string `json:"name,omitempty"`
 	// Addr is the address of the breakpoint.
 	Addr uint64 `json:"addr"`
 	// File is the source file for the breakpoint.
@@ -42,6 +65,136 @@ type Breakpoint struct {
 	Goroutine bool `json:"goroutine"`
 	// variables to evaluate
 	Variables []string `json:"variables,omitempty"`
+	// terminal commands to run each time the breakpoint stops the process
+	Commands []string `json:"commands,omitempty"`
+	// logpoint message, expressions between braces, like "retry {n}", are
+	// evaluated at each hit in the scope of the goroutine that hit the
+	// breakpoint
+	LogMessage string `json:"logMessage,omitempty"`
+	// Breakpoint condition, a boolean expression evaluated in the scope of
+	// the goroutine that hit the breakpoint. The breakpoint is only
+	// triggered when the condition is true.
+	Cond string `json:"cond,omitempty"`
+	// if not zero only the goroutine with this ID can hit the breakpoint
+	GoroutineID int `json:"goroutineID,omitempty"`
+	// if not empty only goroutines created by a go statement at this
+	// location spec can hit the breakpoint
+	CreatedAt string `json:"createdAt,omitempty"`
+
+	// number of times the breakpoint has been hit, by goroutine ID
+	HitCount map[string]uint64 `json:"hitCount"`
+	// number of times the breakpoint has been hit
+	TotalHitCount uint64 `json:"totalHitCount"`
+	// number of initial hits that will not stop the process
+	IgnoreCount uint64 `json:"ignoreCount,omitempty"`
+	// if not zero only every StopEvery-th hit will stop the process
+	StopEvery uint64 `json:"stopEvery,omitempty"`
+	// disabled breakpoints are kept, with their ID and attributes, but
+	// never stop the process
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+// DiscardedBreakpoint is a breakpoint, or a watchpoint, that could not
+// be recreated after the process was restarted.
+type DiscardedBreakpoint struct {
+	Breakpoint *Breakpoint `json:"breakpoint,omitempty"`
+	Watchpoint *Watchpoint `json:"watchpoint,omitempty"`
+	Reason     string      `json:"reason"`
+}
+
+// Process is a process being debugged.
+type Process struct {
+	Pid     int  `json:"pid"`
+	Exited  bool `json:"exited"`
+	Current bool `json:"current"`
+}
+
+// Checkpoint is a copy of the process that can be restarted to go back
+// to the state the process had when the checkpoint was taken.
+type Checkpoint struct {
+	ID    int    `json:"id"`
+	PC    uint64 `json:"pc"`
+	Where string `json:"where"`
+}
+
+// Watchpoint suspends process execution when the memory of an
+// expression is accessed.
+type Watchpoint struct {
+	// ID is a unique identifier for the watchpoint, shared with breakpoints.
+	ID int `json:"id"`
+	// Expr is the watched expression.
+	Expr string `json:"expr"`
+	// Addr is the address of the watched memory.
+	Addr uint64 `json:"addr"`
+	// Size is the size of the watched memory.
+	Size int `json:"size"`
+	// Type is the kind of access that triggers the watchpoint, one of
+	// "write", "read" or "rw".
+	Type string `json:"type"`
+	// Software is true for software watchpoints, implemented by single
+	// stepping the process, which are very slow.
+	Software bool `json:"software,omitempty"`
+	// value of the expression before the last hit
+	OldValue string `json:"oldValue"`
+	// value of the expression after the last hit
+	Value string `json:"value"`
+	// fields, or array elements, of the expression changed by the last hit
+	ChangedFields []string `json:"changedFields,omitempty"`
+	// number of times the watchpoint has been hit
+	TotalHitCount uint64 `json:"totalHitCount"`
+}
+
+// SyscallCatchpoint stops the debugged process when a thread enters or
+// returns from one of a set of system calls.
+type SyscallCatchpoint struct {
+	// ID is a unique identifier for the catchpoint, shared with breakpoints.
+	ID int `json:"id"`
+	// Syscalls are the names of the caught system calls, all of them if
+	// empty.
+	Syscalls []string `json:"syscalls,omitempty"`
+	// number of times the catchpoint has been hit
+	TotalHitCount uint64 `json:"totalHitCount"`
+}
+
+// SyscallStop is a system call the debugged process stopped at.
+type SyscallStop struct {
+	// Catchpoint is the ID of the catchpoint that was hit.
+	Catchpoint int `json:"catchpoint"`
+	// Number and Name identify the system call.
+	Number int    `json:"number"`
+	Name   string `json:"name"`
+	// Args are the arguments of the system call, as passed in registers.
+	Args []uint64 `json:"args"`
+	// Exit is true if the thread is returning from the system call,
+	// Return is the value it returned.
+	Exit   bool  `json:"exit"`
+	Return int64 `json:"return"`
+	// Stacktrace is the stack of the goroutine making the system call.
+	Stacktrace []Stackframe `json:"stacktrace,omitempty"`
+}
+
+// Signal is a signal received by a thread of the debugged process.
+type Signal struct {
+	ThreadID int    `json:"threadID"`
+	Number   int    `json:"number"`
+	Name     string `json:"name"`
+	// Stopped is true if the signal stopped the process.
+	Stopped bool `json:"stopped"`
+	// Passed is true if the signal is delivered to the process.
+	Passed bool `json:"passed"`
+}
+
+// SignalPolicy says what happens when the debugged process receives a
+// signal.
+type SignalPolicy struct {
+	Number int    `json:"number"`
+	Name   string `json:"name"`
+	// Stop the process, implies Print.
+	Stop bool `json:"stop"`
+	// Report the signal in DebuggerState.
+	Print bool `json:"print"`
+	// Deliver the signal to the process.
+	Pass bool `json:"pass"`
 }
 
 // Thread is a thread within the debugged process.
@@ -56,6 +209,9 @@ type Thread struct {
 	Line int `json:"line"`
 	// Function is function information at the program counter. May be nil.
 	Function *Function `json:"function,omitempty"`
+	// ReturnValues are the values returned by the function the thread
+	// just stepped out of.
+	ReturnValues []Variable `json:"returnValues,omitempty"`
 }
 
 type Location struct {
@@ -130,6 +286,13 @@ type DebuggerCommand struct {
 	// GoroutineID is used to specify which thread to use with the SwitchGoroutine
 	// command.
 	GoroutineID int `json:"goroutineID,omitempty"`
+	// Location is the location spec to run to with the RunTo command, or
+	// to jump to with the Jump command. If empty RunTo runs until a line
+	// past the current one in the current frame, to exit a loop.
+	Location string `json:"location,omitempty"`
+	// ProcessID is the pid of the process to switch to with the
+	// SwitchProcess command.
+	ProcessID int `json:"processID,omitempty"`
 }
 
 // Informations about the current breakpoint
@@ -138,6 +301,11 @@ type BreakpointInfo struct {
 	Goroutine  *Goroutine   `json:"goroutine,omitempty"`
 	Variables  []Variable   `json:"variables,omitempty"`
 	Arguments  []Variable   `json:"arguments,omitempty"`
+	// value passed to panic, or message of the fatal error, when stopped
+	// on an unrecovered panic or fatal error
+	Panic *Variable `json:"panic,omitempty"`
+	// rendered logpoint message
+	LogMessage string `json:"logMessage,omitempty"`
 }
 
 type EvalScope struct {
@@ -148,14 +316,25 @@ type EvalScope struct {
 const (
 	// Continue resumes process execution.
 	Continue = "continue"
-	// Step continues for a single instruction, entering function calls.
+	// Step continues to the next source line, entering function calls.
 	Step = "step"
+	// StepInstruction continues for a single instruction.
+	StepInstruction = "stepInstruction"
 	// Next continues to the next source line, not entering function calls.
 	Next = "next"
+	// StepOut continues until the current function returns.
+	StepOut = "stepOut"
+	// RunTo continues until a location is reached.
+	RunTo = "runTo"
+	// Jump moves the current thread to a location in the current
+	// function, without executing the code in between.
+	Jump = "jump"
 	// SwitchThread switches the debugger's current thread context.
 	SwitchThread = "switchThread"
 	// SwitchGoroutine switches the debugger's current thread context to the thread running the specified goroutine
 	SwitchGoroutine = "switchGoroutine"
+	// SwitchProcess switches the debugger's current process.
+	SwitchProcess = "switchProcess"
 	// Halt suspends the process.
 	Halt = "halt"
 )
@@ -13,8 +13,23 @@ type Client interface {
 	// Detach detaches the debugger, optionally killing the process.
 	Detach(killProcess bool) error
 
-	// Restarts program.
-	Restart() error
+	// Restarts program, returns the breakpoints that could not be
+	// recreated.
+	Restart() ([]api.DiscardedBreakpoint, error)
+	// Rebuild compiles the program again and restarts it, returns the
+	// breakpoints that could not be recreated.
+	Rebuild() ([]api.DiscardedBreakpoint, error)
+
+	// Checkpoint saves the state of the stopped process, where describes
+	// the checkpoint. Returns the ID of the checkpoint.
+	Checkpoint(where string) (int, error)
+	// ListCheckpoints lists the checkpoints taken so far.
+	ListCheckpoints() ([]api.Checkpoint, error)
+	// RestartCheckpoint replaces the process with a copy of a checkpoint.
+	RestartCheckpoint(id int) error
+
+	// ListProcesses lists the processes being debugged.
+	ListProcesses() ([]api.Process, error)
 
 	// GetState returns the current debugger state.
 	GetState() (*api.DebuggerState, error)
@@ -25,10 +40,22 @@ type Client interface {
 	Next() (*api.DebuggerState, error)
 	// Step continues to the next source line, entering function calls.
 	Step() (*api.DebuggerState, error)
+	// StepInstruction executes a single instruction.
+	StepInstruction() (*api.DebuggerState, error)
+	// StepOut continues until the current function returns.
+	StepOut() (*api.DebuggerState, error)
+	// RunTo continues until the location spec is reached, or until a line
+	// past the current one in the current frame if it is empty.
+	RunTo(locspec string) (*api.DebuggerState, error)
+	// Jump moves the current thread to the location spec, which must be
+	// in the current function, without running the code in between.
+	Jump(locspec string) (*api.DebuggerState, error)
 	// SwitchThread switches the current thread context.
 	SwitchThread(threadID int) (*api.DebuggerState, error)
 	// SwitchGoroutine switches the current goroutine (and the current thread as well)
 	SwitchGoroutine(goroutineID int) (*api.DebuggerState, error)
+	// SwitchProcess switches the current process.
+	SwitchProcess(pid int) (*api.DebuggerState, error)
 	// Halt suspends the process.
 	Halt() (*api.DebuggerState, error)
 
@@ -36,11 +63,42 @@ type Client interface {
 	GetBreakpoint(id int) (*api.Breakpoint, error)
 	// CreateBreakpoint creates a new breakpoint.
 	CreateBreakpoint(*api.Breakpoint) (*api.Breakpoint, error)
-	// ListBreakpoints gets all breakpoints.
+	// ListBreakpoints gets all breakpoints, except the ones set by delve itself.
 	ListBreakpoints() ([]*api.Breakpoint, error)
+	// ListPanicBreakpoints gets the breakpoints set by delve to stop the
+	// process on unrecovered panics and fatal errors.
+	ListPanicBreakpoints() ([]*api.Breakpoint, error)
+	// SetPanicBreakpoints sets or clears the panic breakpoints, they are
+	// set by default.
+	SetPanicBreakpoints(enabled bool) ([]*api.Breakpoint, error)
+	// AmendBreakpoint changes the attributes of an existing breakpoint,
+	// including whether it is enabled, bp.ID selects the breakpoint.
+	AmendBreakpoint(bp *api.Breakpoint) error
 	// ClearBreakpoint deletes a breakpoint by ID.
 	ClearBreakpoint(id int) (*api.Breakpoint, error)
 
+	// CreateWatchpoint sets a watchpoint on the memory of expr, wtype is
+	// one of "write", "read" or "rw".
+	CreateWatchpoint(scope api.EvalScope, expr, wtype string) (*api.Watchpoint, error)
+	// ListWatchpoints gets all watchpoints.
+	ListWatchpoints() ([]*api.Watchpoint, error)
+	// ClearWatchpoint deletes a watchpoint by ID.
+	ClearWatchpoint(id int) (*api.Watchpoint, error)
+	// CreateSyscallCatchpoint stops the process at the entry and exit of
+	// the named system calls, or of every system call if names is empty.
+	CreateSyscallCatchpoint(names []string) (*api.SyscallCatchpoint, error)
+	// ListSyscallCatchpoints gets all syscall catchpoints.
+	ListSyscallCatchpoints() ([]*api.SyscallCatchpoint, error)
+	// ClearSyscallCatchpoint deletes a syscall catchpoint by ID.
+	ClearSyscallCatchpoint(id int) (*api.SyscallCatchpoint, error)
+
+	// ListSignalPolicies gets what happens when the process receives each signal.
+	ListSignalPolicies() ([]api.SignalPolicy, error)
+	// HandleSignal changes what happens when the process receives signal,
+	// actions are stop, nostop, print, noprint, pass, nopass, ignore and
+	// noignore, like in gdb's handle command.
+	HandleSignal(signal string, actions []string) (*api.SignalPolicy, error)
+
 	// ListThreads lists all threads.
 	ListThreads() ([]*api.Thread, error)
 	// GetThread gets a thread by its ID.
@@ -56,6 +114,10 @@ type Client interface {
 	// SetVariable sets the value of a variable
 	SetVariable(scope api.EvalScope, symbol, value string) error
 
+	// Call calls a function of the target process on the current
+	// goroutine and returns its return values.
+	Call(expr string) ([]api.Variable, error)
+
 	// ListSources lists all source files in the process matching filter.
 	ListSources(filter string) ([]string, error)
 	// ListFunctions lists all functions in the process matching filter.
@@ -16,4 +16,17 @@ type Config struct {
 	// AttachPid is the PID of an existing process to which the debugger should
 	// attach.
 	AttachPid int
+	// Rebuild compiles the program again, it is nil if the program was
+	// not compiled by delve. If compilation fails the error contains
+	// the compiler output.
+	Rebuild func() error
+	// FollowFork chooses which processes are debugged after a fork,
+	// "parent", "child" or "both".
+	FollowFork string
+	// MaxMapEntries is the number of entries of a map loaded when
+	// printing it, zero means the default.
+	MaxMapEntries int
+	// NoPanicBreakpoints disables the breakpoints stopping the process
+	// on unrecovered panics and fatal errors.
+	NoPanicBreakpoints bool
 }
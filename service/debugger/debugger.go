@@ -4,8 +4,11 @@ import (
 	"debug/gosym"
 	"errors"
 	"fmt"
+	"go/ast"
+	"go/parser"
 	"log"
 	"regexp"
+	"sort"
 
 	"github.com/derekparker/delve/proc"
 	"github.com/derekparker/delve/service/api"
@@ -23,6 +26,12 @@ import (
 type Debugger struct {
 	config  *Config
 	process *proc.Process
+	// All the processes being debugged, the current one included, in the
+	// order they were created. Children are added when they are forked,
+	// with the "both" follow mode.
+	processes []*proc.Process
+	// Whether the processes stop on unrecovered panics and fatal errors.
+	panicBreakpoints bool
 }
 
 // Config provides the configuration to start a Debugger.
@@ -36,12 +45,25 @@ type Config struct {
 	// AttachPid is the PID of an existing process to which the debugger should
 	// attach.
 	AttachPid int
+	// Rebuild compiles the program again, it is nil if the program can
+	// not be rebuilt.
+	Rebuild func() error
+	// FollowFork chooses which processes are debugged after a fork,
+	// "parent", "child" or "both". Empty means "parent".
+	FollowFork string
+	// MaxMapEntries is the number of entries of a map loaded when
+	// printing it, zero means the default of the proc package.
+	MaxMapEntries int
+	// NoPanicBreakpoints disables the breakpoints stopping the process
+	// on unrecovered panics and fatal errors.
+	NoPanicBreakpoints bool
 }
 
 // New creates a new Debugger.
 func New(config *Config) (*Debugger, error) {
 	d := &Debugger{
-		config: config,
+		config:           config,
+		panicBreakpoints: !config.NoPanicBreakpoints,
 	}
 
 	// Create the process by either attaching or launching.
@@ -60,50 +82,215 @@ func New(config *Config) (*Debugger, error) {
 		}
 		d.process = p
 	}
+	if d.panicBreakpoints {
+		if err := d.process.SetPanicBreakpoints(); err != nil {
+			log.Printf("could not set panic breakpoints: %s", err)
+		}
+	}
+	if err := d.configureProcess(d.process); err != nil {
+		d.Detach(d.config.AttachPid == 0)
+		return nil, err
+	}
+	d.processes = []*proc.Process{d.process}
 	return d, nil
 }
 
+// Applies the options of the configuration to the process p.
+func (d *Debugger) configureProcess(p *proc.Process) error {
+	if d.config.MaxMapEntries != 0 {
+		if err := p.SetMaxMapEntries(d.config.MaxMapEntries); err != nil {
+			return err
+		}
+	}
+	if d.config.FollowFork == "" {
+		return nil
+	}
+	return p.SetFollowMode(proc.FollowMode(d.config.FollowFork))
+}
+
 func (d *Debugger) ProcessPid() int {
 	return d.process.Pid
 }
 
+// Detach detaches from all the processes, or kills them. Processes
+// launched by the debugger are always killed, children first since the
+// launched process kills its whole process group.
 func (d *Debugger) Detach(kill bool) error {
-	if d.config.AttachPid != 0 {
-		return d.process.Detach(kill)
-	} else {
-		return d.process.Kill()
+	var err error
+	for i := len(d.processes) - 1; i >= 0; i-- {
+		p := d.processes[i]
+		var perr error
+		if d.config.AttachPid != 0 {
+			perr = p.Detach(kill)
+		} else {
+			perr = p.Kill()
+		}
+		if perr != nil {
+			if p != d.process {
+				log.Printf("could not detach from process %d: %s", p.Pid, perr)
+				continue
+			}
+			err = perr
+		}
 	}
+	return err
 }
 
-func (d *Debugger) Restart() error {
+// Restart kills the process and launches it again, recreating the
+// breakpoints, watchpoints and syscall catchpoints with the same IDs and
+// attributes. Breakpoints are resolved again from their function or file
+// and line, so that they survive a rebuild of the program, watchpoints
+// are only recreated on package variables. The breakpoints and
+// watchpoints that can not be recreated are returned.
+func (d *Debugger) Restart() ([]api.DiscardedBreakpoint, error) {
+	oldbps := make([]*proc.Breakpoint, 0, len(d.process.Breakpoints))
+	for _, bp := range d.process.Breakpoints {
+		if !bp.Temp && !bp.Internal() {
+			oldbps = append(oldbps, bp)
+		}
+	}
+	sort.Sort(breakpointsByID(oldbps))
+	onEntry := make(map[int]bool, len(oldbps))
+	for _, bp := range oldbps {
+		addr, err := d.process.FindFunctionLocation(bp.FunctionName, true, 0)
+		onEntry[bp.ID] = err == nil && addr == bp.Addr
+	}
+	oldwps := make([]int, 0, len(d.process.Watchpoints))
+	for id := range d.process.Watchpoints {
+		oldwps = append(oldwps, id)
+	}
+	sort.Ints(oldwps)
+	oldcps := make([]int, 0, len(d.process.SyscallCatchpoints))
+	for id := range d.process.SyscallCatchpoints {
+		oldcps = append(oldcps, id)
+	}
+	sort.Ints(oldcps)
+
 	if !d.process.Exited() {
 		if d.process.Running() {
 			d.process.Halt()
 		}
 		// Ensure the process is in a PTRACE_STOP.
 		if err := sys.Kill(d.ProcessPid(), sys.SIGSTOP); err != nil {
-			return err
+			return nil, err
 		}
 		if err := d.Detach(true); err != nil {
-			return err
+			return nil, err
 		}
 	}
+	oldp := d.process
 	p, err := proc.Launch(d.config.ProcessArgs)
 	if err != nil {
-		return fmt.Errorf("could not launch process: %s", err)
+		return nil, fmt.Errorf("could not launch process: %s", err)
 	}
-	for addr, bp := range d.process.Breakpoints {
-		if bp.Temp {
-			continue
+	for _, sig := range proc.Signals() {
+		p.SetSignalPolicy(sig, oldp.SignalPolicy(sig))
+	}
+	d.process = p
+	d.processes = []*proc.Process{p}
+	if d.panicBreakpoints {
+		if err := p.SetPanicBreakpoints(); err != nil {
+			log.Printf("could not set panic breakpoints: %s", err)
+		}
+	}
+	if err := d.configureProcess(p); err != nil {
+		return nil, err
+	}
+
+	discarded := []api.DiscardedBreakpoint{}
+	for _, oldbp := range oldbps {
+		var addr uint64
+		if onEntry[oldbp.ID] {
+			addr, err = p.FindFunctionLocation(oldbp.FunctionName, true, 0)
+		} else {
+			addr, err = p.FindFileLocation(oldbp.File, oldbp.Line)
 		}
-		if _, err := p.SetBreakpoint(addr); err != nil {
+		if err == nil {
+			err = restoreBreakpoint(p, oldbp, addr)
+		}
+		if err != nil {
+			discarded = append(discarded, api.DiscardedBreakpoint{Breakpoint: api.ConvertBreakpoint(oldbp), Reason: err.Error()})
+		}
+	}
+	for _, id := range oldwps {
+		oldwp := oldp.Watchpoints[id]
+		if _, err := p.RestoreWatchpoint(oldwp); err != nil {
+			discarded = append(discarded, api.DiscardedBreakpoint{Watchpoint: api.ConvertWatchpoint(oldwp), Reason: err.Error()})
+		}
+	}
+	for _, id := range oldcps {
+		if _, err := p.SetSyscallCatchpointWithID(id, oldp.SyscallCatchpoints[id].Syscalls); err != nil {
+			return nil, err
+		}
+	}
+	return discarded, nil
+}
+
+// Checkpoint saves the state of the stopped process in a copy of it and
+// returns the ID of the checkpoint.
+func (d *Debugger) Checkpoint(where string) (int, error) {
+	return d.process.Checkpoint(where)
+}
+
+// Checkpoints returns the checkpoints taken so far.
+func (d *Debugger) Checkpoints() []api.Checkpoint {
+	cps := []api.Checkpoint{}
+	for _, cp := range d.process.Checkpoints() {
+		cps = append(cps, api.ConvertCheckpoint(cp))
+	}
+	return cps
+}
+
+// RestartCheckpoint replaces the process with a copy of the checkpoint
+// with the given ID, keeping the current breakpoints.
+func (d *Debugger) RestartCheckpoint(id int) error {
+	log.Printf("restarting checkpoint %d", id)
+	return d.process.RestartCheckpoint(id)
+}
+
+// Rebuild compiles the program again and restarts it, like Restart.
+// If compilation fails the current process is left untouched.
+func (d *Debugger) Rebuild() ([]api.DiscardedBreakpoint, error) {
+	if d.config.Rebuild == nil {
+		return nil, errors.New("the program was not compiled by delve and can not be rebuilt")
+	}
+	if err := d.config.Rebuild(); err != nil {
+		return nil, fmt.Errorf("could not rebuild the program:\n%s", err)
+	}
+	return d.Restart()
+}
+
+// Sets a breakpoint at addr with the ID and attributes of oldbp.
+func restoreBreakpoint(p *proc.Process, oldbp *proc.Breakpoint, addr uint64) error {
+	bp, err := p.SetBreakpointWithID(oldbp.ID, addr)
+	if err != nil {
+		return err
+	}
+	if !oldbp.Enabled {
+		if _, err := p.DisableBreakpoint(bp.Addr); err != nil {
 			return err
 		}
 	}
-	d.process = p
+	bp.Cond = oldbp.Cond
+	bp.GoroutineID = oldbp.GoroutineID
+	bp.CreatedAt = oldbp.CreatedAt
+	bp.Tracepoint = oldbp.Tracepoint
+	bp.Goroutine = oldbp.Goroutine
+	bp.Stacktrace = oldbp.Stacktrace
+	bp.Variables = oldbp.Variables
+	bp.Commands = oldbp.Commands
+	bp.LogMessage = oldbp.LogMessage
+	bp.IgnoreCount = oldbp.IgnoreCount
+	bp.StopEvery = oldbp.StopEvery
 	return nil
 }
 
+type breakpointsByID []*proc.Breakpoint
+
+func (a breakpointsByID) Len() int           { return len(a) }
+func (a breakpointsByID) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a breakpointsByID) Less(i, j int) bool { return a[i].ID < a[j].ID }
+
 func (d *Debugger) State() (*api.DebuggerState, error) {
 	var (
 		state     *api.DebuggerState
@@ -125,11 +312,38 @@ func (d *Debugger) State() (*api.DebuggerState, error) {
 		breakpoint = api.ConvertBreakpoint(bp)
 	}
 
+	var watchpoint *api.Watchpoint
+	if wp := d.process.CurrentWatchpoint(); wp != nil {
+		watchpoint = api.ConvertWatchpoint(wp)
+	}
+
+	var syscallStop *api.SyscallStop
+	if s := d.process.CurrentSyscall(); s != nil {
+		syscallStop = api.ConvertSyscallStop(s)
+		rawlocs, err := d.process.CurrentThread.Stacktrace(syscallStackDepth)
+		if err == nil {
+			syscallStop.Stacktrace, err = d.convertStacktrace(rawlocs, false)
+		}
+		if err != nil {
+			log.Printf("could not get the stack of system call %s: %s", s.Name, err)
+		}
+	}
+
 	state = &api.DebuggerState{
+		Pid:               d.process.Pid,
 		Breakpoint:        breakpoint,
+		Watchpoint:        watchpoint,
+		Syscall:           syscallStop,
 		CurrentThread:     thread,
 		SelectedGoroutine: goroutine,
 		Exited:            d.process.Exited(),
+		ExecPath:          d.process.ExecPath,
+	}
+	for _, child := range d.process.Forked {
+		state.Forked = append(state.Forked, child.Pid)
+	}
+	for _, s := range d.process.Signals {
+		state.Signals = append(state.Signals, api.ConvertSignal(s))
 	}
 
 	return state, nil
@@ -162,13 +376,105 @@ func (d *Debugger) CreateBreakpoint(requestedBp *api.Breakpoint) (*api.Breakpoin
 	if err != nil {
 		return nil, err
 	}
+	if err := d.setBreakpointInfo(bp, requestedBp); err != nil {
+		d.process.ClearBreakpoint(bp.Addr)
+		return nil, err
+	}
+	createdBp = api.ConvertBreakpoint(bp)
+	log.Printf("created breakpoint: %#v", createdBp)
+	return createdBp, nil
+}
+
+// AmendBreakpoint changes the attributes of the existing breakpoint with
+// the same ID as amend, its location can not be changed.
+func (d *Debugger) AmendBreakpoint(amend *api.Breakpoint) error {
+	bp, ok := d.process.FindBreakpointByID(amend.ID)
+	if !ok || bp.Temp || bp.Internal() {
+		return fmt.Errorf("no breakpoint with id %d", amend.ID)
+	}
+	if err := d.setBreakpointInfo(bp, amend); err != nil {
+		return err
+	}
+	log.Printf("amended breakpoint: %#v", api.ConvertBreakpoint(bp))
+	return nil
+}
+
+func parseBreakpointCond(requestedBp *api.Breakpoint) (ast.Expr, error) {
+	if requestedBp.Cond == "" {
+		return nil, nil
+	}
+	cond, err := parser.ParseExpr(requestedBp.Cond)
+	if err != nil {
+		return nil, fmt.Errorf("invalid breakpoint condition %q: %s", requestedBp.Cond, err)
+	}
+	return cond, nil
+}
+
+// Resolves the location spec of a go statement.
+func (d *Debugger) goStatementLocation(spec string) (*proc.GoStatementLocation, error) {
+	loc, err := parseLocationSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+	pc, err := d.process.PC()
+	if err != nil {
+		return nil, err
+	}
+	locs, err := loc.Find(d, pc, spec)
+	if err != nil {
+		return nil, err
+	}
+	if len(locs) != 1 {
+		return nil, fmt.Errorf("location %q matches %d locations", spec, len(locs))
+	}
+	file, line, fn := d.process.PCToLine(locs[0].PC)
+	if fn == nil {
+		return nil, fmt.Errorf("could not find function for location %q", spec)
+	}
+	r := &proc.GoStatementLocation{Spec: spec, Function: fn.Name, File: file, Line: line}
+	if nloc, ok := loc.(*NormalLocationSpec); ok && nloc.LineOffset < 0 {
+		// A function name without a line matches the whole function.
+		r.File, r.Line = "", 0
+	}
+	return r, nil
+}
+
+// Copies the attributes of requestedBp to bp and enables or disables it.
+func (d *Debugger) setBreakpointInfo(bp *proc.Breakpoint, requestedBp *api.Breakpoint) error {
+	cond, err := parseBreakpointCond(requestedBp)
+	if err != nil {
+		return err
+	}
+	var createdAt *proc.GoStatementLocation
+	if requestedBp.CreatedAt != "" {
+		createdAt, err = d.goStatementLocation(requestedBp.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("invalid goroutine creation location: %s", err)
+		}
+	}
+	if _, err := parseLogMessage(requestedBp.LogMessage); err != nil {
+		return fmt.Errorf("invalid log message %q: %s", requestedBp.LogMessage, err)
+	}
+	if requestedBp.Disabled {
+		_, err = d.process.DisableBreakpoint(bp.Addr)
+	} else {
+		_, err = d.process.EnableBreakpoint(bp.Addr)
+	}
+	if err != nil {
+		return err
+	}
+	bp.Cond = cond
+	bp.GoroutineID = requestedBp.GoroutineID
+	bp.CreatedAt = createdAt
 	bp.Tracepoint = requestedBp.Tracepoint
 	bp.Goroutine = requestedBp.Goroutine
 	bp.Stacktrace = requestedBp.Stacktrace
 	bp.Variables = requestedBp.Variables
-	createdBp = api.ConvertBreakpoint(bp)
-	log.Printf("created breakpoint: %#v", createdBp)
-	return createdBp, nil
+	bp.Commands = requestedBp.Commands
+	bp.LogMessage = requestedBp.LogMessage
+	bp.IgnoreCount = requestedBp.IgnoreCount
+	bp.StopEvery = requestedBp.StopEvery
+	return nil
 }
 
 func (d *Debugger) ClearBreakpoint(requestedBp *api.Breakpoint) (*api.Breakpoint, error) {
@@ -185,7 +491,7 @@ func (d *Debugger) ClearBreakpoint(requestedBp *api.Breakpoint) (*api.Breakpoint
 func (d *Debugger) Breakpoints() []*api.Breakpoint {
 	bps := []*api.Breakpoint{}
 	for _, bp := range d.process.Breakpoints {
-		if bp.Temp {
+		if bp.Temp || bp.Internal() {
 			continue
 		}
 		bps = append(bps, api.ConvertBreakpoint(bp))
@@ -193,6 +499,42 @@ func (d *Debugger) Breakpoints() []*api.Breakpoint {
 	return bps
 }
 
+// PanicBreakpoints returns the breakpoints set by the debugger to stop
+// the process on unrecovered panics and fatal errors.
+func (d *Debugger) PanicBreakpoints() []*api.Breakpoint {
+	bps := []*api.Breakpoint{}
+	for _, bp := range d.process.Breakpoints {
+		if bp.Internal() {
+			bps = append(bps, api.ConvertBreakpoint(bp))
+		}
+	}
+	return bps
+}
+
+// SetPanicBreakpoints sets or clears the panic breakpoints in all the
+// processes being debugged, and in the ones started by Restart.
+func (d *Debugger) SetPanicBreakpoints(enabled bool) ([]*api.Breakpoint, error) {
+	if enabled != d.panicBreakpoints {
+		for _, p := range d.processes {
+			if p.Exited() {
+				continue
+			}
+			var err error
+			if enabled {
+				err = p.SetPanicBreakpoints()
+			} else {
+				err = p.ClearPanicBreakpoints()
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		d.panicBreakpoints = enabled
+		log.Printf("panic breakpoints enabled: %t", enabled)
+	}
+	return d.PanicBreakpoints(), nil
+}
+
 func (d *Debugger) FindBreakpoint(id int) *api.Breakpoint {
 	for _, bp := range d.Breakpoints() {
 		if bp.ID == id {
@@ -202,6 +544,117 @@ func (d *Debugger) FindBreakpoint(id int) *api.Breakpoint {
 	return nil
 }
 
+func (d *Debugger) CreateWatchpoint(scope api.EvalScope, expr, wtype string) (*api.Watchpoint, error) {
+	var t proc.WatchType
+	switch wtype {
+	case "", "write":
+		t = proc.WatchWrite
+	case "read":
+		t = proc.WatchRead
+	case "rw":
+		t = proc.WatchReadWrite
+	default:
+		return nil, fmt.Errorf("unknown watchpoint type %q", wtype)
+	}
+	s, err := d.process.ConvertEvalScope(scope.GoroutineID, scope.Frame)
+	if err != nil {
+		return nil, err
+	}
+	wp, err := d.process.SetWatchpoint(s, expr, t)
+	if err != nil {
+		return nil, err
+	}
+	createdWp := api.ConvertWatchpoint(wp)
+	log.Printf("created watchpoint: %#v", createdWp)
+	return createdWp, nil
+}
+
+func (d *Debugger) ClearWatchpoint(id int) (*api.Watchpoint, error) {
+	wp, err := d.process.ClearWatchpoint(id)
+	if err != nil {
+		return nil, err
+	}
+	clearedWp := api.ConvertWatchpoint(wp)
+	log.Printf("cleared watchpoint: %#v", clearedWp)
+	return clearedWp, nil
+}
+
+func (d *Debugger) Watchpoints() []*api.Watchpoint {
+	wps := []*api.Watchpoint{}
+	for _, wp := range d.process.Watchpoints {
+		wps = append(wps, api.ConvertWatchpoint(wp))
+	}
+	return wps
+}
+
+// SignalPolicies returns what happens when the process receives each
+// signal.
+func (d *Debugger) SignalPolicies() []api.SignalPolicy {
+	policies := []api.SignalPolicy{}
+	for _, sig := range proc.Signals() {
+		policies = append(policies, api.ConvertSignalPolicy(sig, d.process.SignalPolicy(sig)))
+	}
+	return policies
+}
+
+// HandleSignal changes what happens when the processes receive signal,
+// like gdb's handle command. Actions are stop, nostop, print, noprint,
+// pass, nopass, ignore and noignore, see proc.SignalPolicy.Set.
+func (d *Debugger) HandleSignal(signal string, actions []string) (*api.SignalPolicy, error) {
+	sig, err := proc.ParseSignal(signal)
+	if err != nil {
+		return nil, err
+	}
+	policy := d.process.SignalPolicy(sig)
+	for _, action := range actions {
+		if err := policy.Set(action); err != nil {
+			return nil, err
+		}
+	}
+	for _, p := range d.processes {
+		if err := p.SetSignalPolicy(sig, policy); err != nil {
+			return nil, err
+		}
+	}
+	p := api.ConvertSignalPolicy(sig, d.process.SignalPolicy(sig))
+	log.Printf("changed signal policy: %#v", p)
+	return &p, nil
+}
+
+// Max number of frames of the stack reported for a system call stop.
+const syscallStackDepth = 20
+
+// CreateSyscallCatchpoint stops the process at the entry and exit of the
+// system calls with the given names, or of every system call if names
+// is empty.
+func (d *Debugger) CreateSyscallCatchpoint(names []string) (*api.SyscallCatchpoint, error) {
+	cp, err := d.process.SetSyscallCatchpoint(names)
+	if err != nil {
+		return nil, err
+	}
+	createdCp := api.ConvertSyscallCatchpoint(cp)
+	log.Printf("created syscall catchpoint: %#v", createdCp)
+	return createdCp, nil
+}
+
+func (d *Debugger) ClearSyscallCatchpoint(id int) (*api.SyscallCatchpoint, error) {
+	cp, err := d.process.ClearSyscallCatchpoint(id)
+	if err != nil {
+		return nil, err
+	}
+	clearedCp := api.ConvertSyscallCatchpoint(cp)
+	log.Printf("cleared syscall catchpoint: %#v", clearedCp)
+	return clearedCp, nil
+}
+
+func (d *Debugger) SyscallCatchpoints() []*api.SyscallCatchpoint {
+	cps := []*api.SyscallCatchpoint{}
+	for _, cp := range d.process.SyscallCatchpoints {
+		cps = append(cps, api.ConvertSyscallCatchpoint(cp))
+	}
+	return cps
+}
+
 func (d *Debugger) Threads() []*api.Thread {
 	threads := []*api.Thread{}
 	for _, th := range d.process.Threads {
@@ -223,9 +676,15 @@ func (d *Debugger) FindThread(id int) *api.Thread {
 func (d *Debugger) Command(command *api.DebuggerCommand) (*api.DebuggerState, error) {
 	var err error
 	switch command.Name {
-	case api.Continue:
-		log.Print("continuing")
-		err = d.process.Continue()
+	case api.Continue, api.RunTo:
+		if command.Name == api.Continue {
+			log.Print("continuing")
+			err = d.process.Continue()
+		} else {
+			log.Printf("running to %q", command.Location)
+			err = d.runTo(command.Location)
+		}
+		d.addForked()
 		state, stateErr := d.State()
 		if stateErr != nil {
 			return state, stateErr
@@ -234,6 +693,9 @@ func (d *Debugger) Command(command *api.DebuggerCommand) (*api.DebuggerState, er
 			if exitedErr, exited := err.(proc.ProcessExitedError); exited {
 				state.Exited = true
 				state.ExitStatus = exitedErr.Status
+				if exitedErr.Signal != 0 {
+					state.ExitSignal = proc.SignalName(exitedErr.Signal)
+				}
 				state.Err = errors.New(exitedErr.Error())
 				return state, nil
 			}
@@ -248,24 +710,107 @@ func (d *Debugger) Command(command *api.DebuggerCommand) (*api.DebuggerState, er
 	case api.Step:
 		log.Print("stepping")
 		err = d.process.Step()
+	case api.StepInstruction:
+		log.Print("single stepping")
+		err = d.process.StepInstruction()
+	case api.StepOut:
+		log.Print("stepping out")
+		err = d.process.StepOut()
+	case api.Jump:
+		log.Printf("jumping to %q", command.Location)
+		err = d.jump(command.Location)
 	case api.SwitchThread:
 		log.Printf("switching to thread %d", command.ThreadID)
 		err = d.process.SwitchThread(command.ThreadID)
 	case api.SwitchGoroutine:
 		log.Printf("switching to goroutine %d", command.GoroutineID)
 		err = d.process.SwitchGoroutine(command.GoroutineID)
+	case api.SwitchProcess:
+		log.Printf("switching to process %d", command.ProcessID)
+		err = d.switchProcess(command.ProcessID)
 	case api.Halt:
 		// RequestManualStop does not invoke any ptrace syscalls, so it's safe to
 		// access the process directly.
 		log.Print("halting")
 		err = d.process.RequestManualStop()
 	}
+	if command.Name != api.Halt {
+		d.addForked()
+	}
 	if err != nil {
 		return nil, err
 	}
 	return d.State()
 }
 
+// Adds the children forked by the current process to the list of
+// processes being debugged.
+func (d *Debugger) addForked() {
+	for _, child := range d.process.Forked {
+		found := false
+		for _, p := range d.processes {
+			if p == child {
+				found = true
+				break
+			}
+		}
+		if !found {
+			log.Printf("process %d forked child %d", d.process.Pid, child.Pid)
+			d.processes = append(d.processes, child)
+		}
+	}
+}
+
+// Processes returns all the processes being debugged.
+func (d *Debugger) Processes() []api.Process {
+	procs := make([]api.Process, 0, len(d.processes))
+	for _, p := range d.processes {
+		procs = append(procs, api.Process{Pid: p.Pid, Exited: p.Exited(), Current: p == d.process})
+	}
+	return procs
+}
+
+// Makes the process with the given pid the current process.
+func (d *Debugger) switchProcess(pid int) error {
+	for _, p := range d.processes {
+		if p.Pid == pid {
+			d.process = p
+			return nil
+		}
+	}
+	return fmt.Errorf("no process with pid %d", pid)
+}
+
+// Continues to the locations matching locspec, or past the current line
+// if locspec is empty.
+func (d *Debugger) runTo(locspec string) error {
+	if locspec == "" {
+		return d.process.RunPastLine()
+	}
+	locs, err := d.FindLocation(api.EvalScope{GoroutineID: -1}, locspec)
+	if err != nil {
+		return err
+	}
+	pcs := make([]uint64, len(locs))
+	for i := range locs {
+		pcs[i] = locs[i].PC
+	}
+	return d.process.RunTo(pcs)
+}
+
+// Moves the current thread to the location matching locspec, which must
+// be in the current function.
+func (d *Debugger) jump(locspec string) error {
+	locs, err := d.FindLocation(api.EvalScope{GoroutineID: -1}, locspec)
+	if err != nil {
+		return err
+	}
+	if len(locs) != 1 {
+		return fmt.Errorf("%s is ambiguous, it matches %d locations", locspec, len(locs))
+	}
+	return d.process.Jump(locs[0].PC)
+}
+
 func (d *Debugger) collectBreakpointInformation(state *api.DebuggerState) error {
 	if state == nil || state.Breakpoint == nil {
 		return nil
@@ -275,6 +820,16 @@ func (d *Debugger) collectBreakpointInformation(state *api.DebuggerState) error
 	bpi := &api.BreakpointInfo{}
 	state.BreakpointInfo = bpi
 
+	if bp.Name == proc.UnrecoveredPanic || bp.Name == proc.FatalError {
+		v, err := d.process.CurrentThread.PanicValue()
+		if err != nil {
+			log.Printf("could not read panic value: %s", err)
+		} else if v != nil {
+			pv := api.ConvertVar(v)
+			bpi.Panic = &pv
+		}
+	}
+
 	if bp.Goroutine {
 		g, err := d.process.CurrentThread.GetG()
 		if err != nil {
@@ -313,6 +868,12 @@ func (d *Debugger) collectBreakpointInformation(state *api.DebuggerState) error
 	if err == nil {
 		bpi.Arguments = vars
 	}
+	if bp.LogMessage != "" {
+		bpi.LogMessage, err = renderLogMessage(s, bp.LogMessage)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -450,6 +1011,16 @@ func (d *Debugger) SetVariableInScope(scope api.EvalScope, symbol, value string)
 	return s.SetVariable(symbol, value)
 }
 
+// Call calls a function of the target process on the current goroutine
+// and returns its return values.
+func (d *Debugger) Call(expr string) ([]api.Variable, error) {
+	pv, err := d.process.Call(expr)
+	if err != nil {
+		return nil, err
+	}
+	return convertVars(pv), nil
+}
+
 func (d *Debugger) Goroutines() ([]*api.Goroutine, error) {
 	goroutines := []*api.Goroutine{}
 	gs, err := d.process.GoroutinesInfo()
@@ -0,0 +1,84 @@
+package debugger
+
+import (
+	"bytes"
+	"fmt"
+	"go/parser"
+	"strings"
+
+	"github.com/derekparker/delve/proc"
+)
+
+// A piece of a logpoint message, either literal text or an expression
+// to evaluate.
+type logSegment struct {
+	text string
+	expr bool
+}
+
+// Splits the message of a logpoint into literal text and the
+// expressions between braces, "{{" and "}}" stand for literal braces.
+func parseLogMessage(msg string) ([]logSegment, error) {
+	var (
+		segments []logSegment
+		buf      bytes.Buffer
+	)
+	for i := 0; i < len(msg); i++ {
+		switch c := msg[i]; {
+		case c == '{' && i+1 < len(msg) && msg[i+1] == '{':
+			buf.WriteByte('{')
+			i++
+		case c == '}' && i+1 < len(msg) && msg[i+1] == '}':
+			buf.WriteByte('}')
+			i++
+		case c == '{':
+			end := strings.IndexByte(msg[i+1:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated expression at %d", i)
+			}
+			expr := strings.TrimSpace(msg[i+1 : i+1+end])
+			if _, err := parser.ParseExpr(expr); err != nil {
+				return nil, fmt.Errorf("invalid expression {%s}: %s", expr, err)
+			}
+			if buf.Len() > 0 {
+				segments = append(segments, logSegment{text: buf.String()})
+				buf.Reset()
+			}
+			segments = append(segments, logSegment{text: expr, expr: true})
+			i += end + 1
+		case c == '}':
+			return nil, fmt.Errorf("unexpected } at %d", i)
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	if buf.Len() > 0 {
+		segments = append(segments, logSegment{text: buf.String()})
+	}
+	return segments, nil
+}
+
+// Renders the message of a logpoint, evaluating its expressions in
+// scope. Expressions that can not be evaluated are replaced by an error
+// message instead of failing, so that one bad expression does not hide
+// the rest of the message.
+func renderLogMessage(s *proc.EvalScope, msg string) (string, error) {
+	segments, err := parseLogMessage(msg)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	for _, seg := range segments {
+		if !seg.expr {
+			buf.WriteString(seg.text)
+			continue
+		}
+		v, err := s.EvalVariable(seg.text)
+		if err != nil {
+			fmt.Fprintf(&buf, "<%s: %s>", seg.text, err)
+			continue
+		}
+		buf.WriteString(v.Value)
+	}
+	return buf.String(), nil
+}
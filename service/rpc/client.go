@@ -42,8 +42,39 @@ func (c *RPCClient) Detach(kill bool) error {
 	return c.call("Detach", kill, nil)
 }
 
-func (c *RPCClient) Restart() error {
-	return c.call("Restart", nil, nil)
+func (c *RPCClient) Restart() ([]api.DiscardedBreakpoint, error) {
+	var discarded []api.DiscardedBreakpoint
+	err := c.call("Restart", nil, &discarded)
+	return discarded, err
+}
+
+func (c *RPCClient) Rebuild() ([]api.DiscardedBreakpoint, error) {
+	var discarded []api.DiscardedBreakpoint
+	err := c.call("Rebuild", nil, &discarded)
+	return discarded, err
+}
+
+func (c *RPCClient) Checkpoint(where string) (int, error) {
+	var id int
+	err := c.call("Checkpoint", where, &id)
+	return id, err
+}
+
+func (c *RPCClient) ListCheckpoints() ([]api.Checkpoint, error) {
+	var checkpoints []api.Checkpoint
+	err := c.call("ListCheckpoints", nil, &checkpoints)
+	return checkpoints, err
+}
+
+func (c *RPCClient) ListProcesses() ([]api.Process, error) {
+	var procs []api.Process
+	err := c.call("ListProcesses", nil, &procs)
+	return procs, err
+}
+
+func (c *RPCClient) RestartCheckpoint(id int) error {
+	var unused int
+	return c.call("RestartCheckpoint", id, &unused)
 }
 
 func (c *RPCClient) GetState() (*api.DebuggerState, error) {
@@ -63,7 +94,11 @@ func (c *RPCClient) Continue() <-chan *api.DebuggerState {
 			}
 			if state.Exited {
 				// Error types apparantly cannot be marshalled by Go correctly. Must reset error here.
-				state.Err = fmt.Errorf("Process %d has exited with status %d", c.ProcessPid(), state.ExitStatus)
+				if state.ExitSignal != "" {
+					state.Err = fmt.Errorf("Process %d was killed by signal %s", c.ProcessPid(), state.ExitSignal)
+				} else {
+					state.Err = fmt.Errorf("Process %d has exited with status %d", c.ProcessPid(), state.ExitStatus)
+				}
 			}
 			ch <- state
 			if err != nil || state.Exited || state.Breakpoint == nil || !state.Breakpoint.Tracepoint {
@@ -87,6 +122,30 @@ func (c *RPCClient) Step() (*api.DebuggerState, error) {
 	return state, err
 }
 
+func (c *RPCClient) StepInstruction() (*api.DebuggerState, error) {
+	state := new(api.DebuggerState)
+	err := c.call("Command", &api.DebuggerCommand{Name: api.StepInstruction}, state)
+	return state, err
+}
+
+func (c *RPCClient) StepOut() (*api.DebuggerState, error) {
+	state := new(api.DebuggerState)
+	err := c.call("Command", &api.DebuggerCommand{Name: api.StepOut}, state)
+	return state, err
+}
+
+func (c *RPCClient) RunTo(locspec string) (*api.DebuggerState, error) {
+	state := new(api.DebuggerState)
+	err := c.call("Command", &api.DebuggerCommand{Name: api.RunTo, Location: locspec}, state)
+	return state, err
+}
+
+func (c *RPCClient) Jump(locspec string) (*api.DebuggerState, error) {
+	state := new(api.DebuggerState)
+	err := c.call("Command", &api.DebuggerCommand{Name: api.Jump, Location: locspec}, state)
+	return state, err
+}
+
 func (c *RPCClient) SwitchThread(threadID int) (*api.DebuggerState, error) {
 	state := new(api.DebuggerState)
 	cmd := &api.DebuggerCommand{
@@ -107,6 +166,16 @@ func (c *RPCClient) SwitchGoroutine(goroutineID int) (*api.DebuggerState, error)
 	return state, err
 }
 
+func (c *RPCClient) SwitchProcess(pid int) (*api.DebuggerState, error) {
+	state := new(api.DebuggerState)
+	cmd := &api.DebuggerCommand{
+		Name:      api.SwitchProcess,
+		ProcessID: pid,
+	}
+	err := c.call("Command", cmd, state)
+	return state, err
+}
+
 func (c *RPCClient) Halt() (*api.DebuggerState, error) {
 	state := new(api.DebuggerState)
 	err := c.call("Command", &api.DebuggerCommand{Name: api.Halt}, state)
@@ -131,12 +200,77 @@ func (c *RPCClient) ListBreakpoints() ([]*api.Breakpoint, error) {
 	return breakpoints, err
 }
 
+func (c *RPCClient) ListPanicBreakpoints() ([]*api.Breakpoint, error) {
+	var breakpoints []*api.Breakpoint
+	err := c.call("ListPanicBreakpoints", nil, &breakpoints)
+	return breakpoints, err
+}
+
+func (c *RPCClient) SetPanicBreakpoints(enabled bool) ([]*api.Breakpoint, error) {
+	var breakpoints []*api.Breakpoint
+	err := c.call("SetPanicBreakpoints", enabled, &breakpoints)
+	return breakpoints, err
+}
+
+func (c *RPCClient) AmendBreakpoint(bp *api.Breakpoint) error {
+	var unused int
+	return c.call("AmendBreakpoint", bp, &unused)
+}
+
 func (c *RPCClient) ClearBreakpoint(id int) (*api.Breakpoint, error) {
 	bp := new(api.Breakpoint)
 	err := c.call("ClearBreakpoint", id, bp)
 	return bp, err
 }
 
+func (c *RPCClient) CreateWatchpoint(scope api.EvalScope, expr, wtype string) (*api.Watchpoint, error) {
+	wp := new(api.Watchpoint)
+	err := c.call("CreateWatchpoint", CreateWatchpointArgs{scope, expr, wtype}, wp)
+	return wp, err
+}
+
+func (c *RPCClient) ListWatchpoints() ([]*api.Watchpoint, error) {
+	var watchpoints []*api.Watchpoint
+	err := c.call("ListWatchpoints", nil, &watchpoints)
+	return watchpoints, err
+}
+
+func (c *RPCClient) ClearWatchpoint(id int) (*api.Watchpoint, error) {
+	wp := new(api.Watchpoint)
+	err := c.call("ClearWatchpoint", id, wp)
+	return wp, err
+}
+
+func (c *RPCClient) CreateSyscallCatchpoint(names []string) (*api.SyscallCatchpoint, error) {
+	cp := new(api.SyscallCatchpoint)
+	err := c.call("CreateSyscallCatchpoint", names, cp)
+	return cp, err
+}
+
+func (c *RPCClient) ListSyscallCatchpoints() ([]*api.SyscallCatchpoint, error) {
+	var catchpoints []*api.SyscallCatchpoint
+	err := c.call("ListSyscallCatchpoints", nil, &catchpoints)
+	return catchpoints, err
+}
+
+func (c *RPCClient) ClearSyscallCatchpoint(id int) (*api.SyscallCatchpoint, error) {
+	cp := new(api.SyscallCatchpoint)
+	err := c.call("ClearSyscallCatchpoint", id, cp)
+	return cp, err
+}
+
+func (c *RPCClient) ListSignalPolicies() ([]api.SignalPolicy, error) {
+	var policies []api.SignalPolicy
+	err := c.call("ListSignalPolicies", nil, &policies)
+	return policies, err
+}
+
+func (c *RPCClient) HandleSignal(signal string, actions []string) (*api.SignalPolicy, error) {
+	policy := new(api.SignalPolicy)
+	err := c.call("HandleSignal", HandleSignalArgs{signal, actions}, policy)
+	return policy, err
+}
+
 func (c *RPCClient) ListThreads() ([]*api.Thread, error) {
 	var threads []*api.Thread
 	err := c.call("ListThreads", nil, &threads)
@@ -155,6 +289,12 @@ func (c *RPCClient) EvalVariable(scope api.EvalScope, symbol string) (*api.Varia
 	return v, err
 }
 
+func (c *RPCClient) Call(expr string) ([]api.Variable, error) {
+	var retvals []api.Variable
+	err := c.call("Call", expr, &retvals)
+	return retvals, err
+}
+
 func (c *RPCClient) SetVariable(scope api.EvalScope, symbol, value string) error {
 	var unused int
 	return c.call("SetSymbol", SetSymbolArgs{scope, symbol, value}, &unused)
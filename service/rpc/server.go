@@ -48,8 +48,12 @@ func (s *RPCServer) Run() error {
 	var err error
 	// Create and start the debugger
 	if s.debugger, err = debugger.New(&debugger.Config{
-		ProcessArgs: s.config.ProcessArgs,
-		AttachPid:   s.config.AttachPid,
+		ProcessArgs:        s.config.ProcessArgs,
+		AttachPid:          s.config.AttachPid,
+		Rebuild:            s.config.Rebuild,
+		FollowFork:         s.config.FollowFork,
+		MaxMapEntries:      s.config.MaxMapEntries,
+		NoPanicBreakpoints: s.config.NoPanicBreakpoints,
 	}); err != nil {
 		return err
 	}
@@ -76,11 +80,43 @@ func (s *RPCServer) Detach(kill bool, ret *int) error {
 	return s.debugger.Detach(kill)
 }
 
-func (s *RPCServer) Restart(arg1 interface{}, arg2 *int) error {
+func (s *RPCServer) Restart(arg1 interface{}, discarded *[]api.DiscardedBreakpoint) error {
 	if s.config.AttachPid != 0 {
 		return errors.New("cannot restart process Delve did not create")
 	}
-	return s.debugger.Restart()
+	var err error
+	*discarded, err = s.debugger.Restart()
+	return err
+}
+
+func (s *RPCServer) Checkpoint(where string, id *int) error {
+	var err error
+	*id, err = s.debugger.Checkpoint(where)
+	return err
+}
+
+func (s *RPCServer) ListCheckpoints(arg interface{}, checkpoints *[]api.Checkpoint) error {
+	*checkpoints = s.debugger.Checkpoints()
+	return nil
+}
+
+func (s *RPCServer) ListProcesses(arg interface{}, procs *[]api.Process) error {
+	*procs = s.debugger.Processes()
+	return nil
+}
+
+func (s *RPCServer) RestartCheckpoint(id int, unused *int) error {
+	*unused = 0
+	return s.debugger.RestartCheckpoint(id)
+}
+
+func (s *RPCServer) Rebuild(arg1 interface{}, discarded *[]api.DiscardedBreakpoint) error {
+	if s.config.AttachPid != 0 {
+		return errors.New("cannot rebuild process Delve did not create")
+	}
+	var err error
+	*discarded, err = s.debugger.Rebuild()
+	return err
 }
 
 func (s *RPCServer) State(arg interface{}, state *api.DebuggerState) error {
@@ -130,6 +166,17 @@ func (s *RPCServer) ListBreakpoints(arg interface{}, breakpoints *[]*api.Breakpo
 	return nil
 }
 
+func (s *RPCServer) ListPanicBreakpoints(arg interface{}, breakpoints *[]*api.Breakpoint) error {
+	*breakpoints = s.debugger.PanicBreakpoints()
+	return nil
+}
+
+func (s *RPCServer) SetPanicBreakpoints(enabled bool, breakpoints *[]*api.Breakpoint) error {
+	var err error
+	*breakpoints, err = s.debugger.SetPanicBreakpoints(enabled)
+	return err
+}
+
 func (s *RPCServer) CreateBreakpoint(bp, newBreakpoint *api.Breakpoint) error {
 	createdbp, err := s.debugger.CreateBreakpoint(bp)
 	if err != nil {
@@ -139,6 +186,11 @@ func (s *RPCServer) CreateBreakpoint(bp, newBreakpoint *api.Breakpoint) error {
 	return nil
 }
 
+func (s *RPCServer) AmendBreakpoint(amend *api.Breakpoint, unused *int) error {
+	*unused = 0
+	return s.debugger.AmendBreakpoint(amend)
+}
+
 func (s *RPCServer) ClearBreakpoint(id int, breakpoint *api.Breakpoint) error {
 	bp := s.debugger.FindBreakpoint(id)
 	if bp == nil {
@@ -152,6 +204,77 @@ func (s *RPCServer) ClearBreakpoint(id int, breakpoint *api.Breakpoint) error {
 	return nil
 }
 
+type CreateWatchpointArgs struct {
+	Scope api.EvalScope
+	Expr  string
+	Type  string
+}
+
+func (s *RPCServer) CreateWatchpoint(args CreateWatchpointArgs, newWatchpoint *api.Watchpoint) error {
+	createdwp, err := s.debugger.CreateWatchpoint(args.Scope, args.Expr, args.Type)
+	if err != nil {
+		return err
+	}
+	*newWatchpoint = *createdwp
+	return nil
+}
+
+func (s *RPCServer) CreateSyscallCatchpoint(names []string, newCatchpoint *api.SyscallCatchpoint) error {
+	createdcp, err := s.debugger.CreateSyscallCatchpoint(names)
+	if err != nil {
+		return err
+	}
+	*newCatchpoint = *createdcp
+	return nil
+}
+
+func (s *RPCServer) ListSyscallCatchpoints(arg interface{}, catchpoints *[]*api.SyscallCatchpoint) error {
+	*catchpoints = s.debugger.SyscallCatchpoints()
+	return nil
+}
+
+func (s *RPCServer) ClearSyscallCatchpoint(id int, catchpoint *api.SyscallCatchpoint) error {
+	deleted, err := s.debugger.ClearSyscallCatchpoint(id)
+	if err != nil {
+		return err
+	}
+	*catchpoint = *deleted
+	return nil
+}
+
+func (s *RPCServer) ListSignalPolicies(arg interface{}, policies *[]api.SignalPolicy) error {
+	*policies = s.debugger.SignalPolicies()
+	return nil
+}
+
+type HandleSignalArgs struct {
+	Signal  string
+	Actions []string
+}
+
+func (s *RPCServer) HandleSignal(args HandleSignalArgs, policy *api.SignalPolicy) error {
+	p, err := s.debugger.HandleSignal(args.Signal, args.Actions)
+	if err != nil {
+		return err
+	}
+	*policy = *p
+	return nil
+}
+
+func (s *RPCServer) ListWatchpoints(arg interface{}, watchpoints *[]*api.Watchpoint) error {
+	*watchpoints = s.debugger.Watchpoints()
+	return nil
+}
+
+func (s *RPCServer) ClearWatchpoint(id int, watchpoint *api.Watchpoint) error {
+	deleted, err := s.debugger.ClearWatchpoint(id)
+	if err != nil {
+		return err
+	}
+	*watchpoint = *deleted
+	return nil
+}
+
 func (s *RPCServer) ListThreads(arg interface{}, threads *[]*api.Thread) error {
 	*threads = s.debugger.Threads()
 	return nil
@@ -260,6 +383,15 @@ func (s *RPCServer) SetSymbol(args SetSymbolArgs, unused *int) error {
 	return s.debugger.SetVariableInScope(args.Scope, args.Symbol, args.Value)
 }
 
+func (s *RPCServer) Call(expr string, retvals *[]api.Variable) error {
+	vars, err := s.debugger.Call(expr)
+	if err != nil {
+		return err
+	}
+	*retvals = vars
+	return nil
+}
+
 func (s *RPCServer) ListSources(filter string, sources *[]string) error {
 	ss, err := s.debugger.Sources(filter)
 	if err != nil {
@@ -5,10 +5,13 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/derekparker/delve/proc"
 	protest "github.com/derekparker/delve/proc/test"
 
 	"github.com/derekparker/delve/service"
@@ -75,7 +78,7 @@ func TestRestart_afterExit(t *testing.T) {
 		if !state.Exited {
 			t.Fatal("expected initial process to have exited")
 		}
-		if err := c.Restart(); err != nil {
+		if _, err := c.Restart(); err != nil {
 			t.Fatal(err)
 		}
 		if c.ProcessPid() == origPid {
@@ -99,7 +102,7 @@ func TestRestart_duringStop(t *testing.T) {
 		if state.Breakpoint == nil {
 			t.Fatal("did not hit breakpoint")
 		}
-		if err := c.Restart(); err != nil {
+		if _, err := c.Restart(); err != nil {
 			t.Fatal(err)
 		}
 		if c.ProcessPid() == origPid {
@@ -115,6 +118,103 @@ func TestRestart_duringStop(t *testing.T) {
 	})
 }
 
+func TestRestart_preserveBreakpoints(t *testing.T) {
+	withTestClient("watchpointtest", t, func(c service.Client) {
+		fp := testProgPath(t, "watchpointtest")
+		bp1, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 9})
+		if err != nil {
+			t.Fatal(err)
+		}
+		bp2, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 11, Tracepoint: true, Stacktrace: 2, Variables: []string{"counter"}, Cond: "counter > 1", Disabled: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.ClearBreakpoint(bp1.ID); err != nil {
+			t.Fatal(err)
+		}
+		bp2, err = c.GetBreakpoint(bp2.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		discarded, err := c.Restart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(discarded) != 0 {
+			t.Fatalf("breakpoints discarded: %v", discarded)
+		}
+		bps, err := c.ListBreakpoints()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(bps) != 1 || !reflect.DeepEqual(bps[0], bp2) {
+			t.Fatalf("breakpoints not preserved: %#v (expected %#v)", bps, bp2)
+		}
+	})
+}
+
+func TestRestart_preserveWatchpoints(t *testing.T) {
+	withTestClient("watchpointtest", t, func(c service.Client) {
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.main", Line: -1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		state := <-c.Continue()
+		if state.Err != nil {
+			t.Fatal(state.Err)
+		}
+		if _, err := c.ClearBreakpoint(bp.ID); err != nil {
+			t.Fatal(err)
+		}
+		wp, err := c.CreateWatchpoint(api.EvalScope{-1, 0}, "counter", "write")
+		if err != nil {
+			t.Fatal(err)
+		}
+		localwp, err := c.CreateWatchpoint(api.EvalScope{-1, 0}, "i", "write")
+		if err != nil {
+			t.Fatal(err)
+		}
+		cp, err := c.CreateSyscallCatchpoint([]string{"openat"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		discarded, err := c.Restart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(discarded) != 1 || discarded[0].Watchpoint == nil || discarded[0].Watchpoint.ID != localwp.ID {
+			t.Fatalf("wrong discarded watchpoints: %#v", discarded)
+		}
+		wps, err := c.ListWatchpoints()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(wps) != 1 || wps[0].ID != wp.ID || wps[0].Expr != "counter" || wps[0].Addr != wp.Addr {
+			t.Fatalf("watchpoint not preserved: %#v (expected %#v)", wps, wp)
+		}
+		cps, err := c.ListSyscallCatchpoints()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(cps) != 1 || cps[0].ID != cp.ID || !reflect.DeepEqual(cps[0].Syscalls, cp.Syscalls) {
+			t.Fatalf("catchpoint not preserved: %#v (expected %#v)", cps, cp)
+		}
+
+		if _, err := c.ClearSyscallCatchpoint(cp.ID); err != nil {
+			t.Fatal(err)
+		}
+		state = <-c.Continue()
+		if state.Err != nil {
+			t.Fatal(state.Err)
+		}
+		if state.Watchpoint == nil || state.Watchpoint.ID != wp.ID || state.Watchpoint.Value != "1" {
+			t.Fatalf("not stopped at the restored watchpoint: %#v", state.Watchpoint)
+		}
+	})
+}
+
 func TestRestart_attachPid(t *testing.T) {
 	// Assert it does not work and returns error.
 	// We cannot restart a process we did not spawn.
@@ -127,6 +227,39 @@ func TestRestart_attachPid(t *testing.T) {
 	}
 }
 
+func TestRebuild_compileError(t *testing.T) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("couldn't start listener: %s\n", err)
+	}
+	defer listener.Close()
+	server := rpc.NewServer(&service.Config{
+		Listener:    listener,
+		ProcessArgs: []string{protest.BuildFixture("continuetestprog").Path},
+		Rebuild: func() error {
+			return fmt.Errorf("./main.go:3:1: syntax error")
+		},
+	}, false)
+	if err := server.Run(); err != nil {
+		t.Fatal(err)
+	}
+	c := rpc.NewClient(listener.Addr().String())
+	defer c.Detach(true)
+
+	origPid := c.ProcessPid()
+	_, err = c.Rebuild()
+	if err == nil || !strings.Contains(err.Error(), "syntax error") {
+		t.Fatalf("compile error not reported: %v", err)
+	}
+	if c.ProcessPid() != origPid {
+		t.Fatal("process restarted after a failed build")
+	}
+	state := <-c.Continue()
+	if !state.Exited {
+		t.Fatalf("expected process to have exited %v", state)
+	}
+}
+
 func TestClientServer_exit(t *testing.T) {
 	withTestClient("continuetestprog", t, func(c service.Client) {
 		state, err := c.GetState()
@@ -156,7 +289,7 @@ func TestClientServer_exit(t *testing.T) {
 	})
 }
 
-func TestClientServer_step(t *testing.T) {
+func TestClientServer_stepInstruction(t *testing.T) {
 	withTestClient("testprog", t, func(c service.Client) {
 		_, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.helloworld", Line: 1})
 		if err != nil {
@@ -168,7 +301,7 @@ func TestClientServer_step(t *testing.T) {
 			t.Fatalf("Unexpected error: %v", stateBefore.Err)
 		}
 
-		stateAfter, err := c.Step()
+		stateAfter, err := c.StepInstruction()
 		if err != nil {
 			t.Fatalf("Unexpected error: %v", err)
 		}
@@ -179,6 +312,51 @@ func TestClientServer_step(t *testing.T) {
 	})
 }
 
+func TestClientServer_step(t *testing.T) {
+	withTestClient("testnextprog", t, func(c service.Client) {
+		fp := testProgPath(t, "testnextprog")
+		_, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 24})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		state := <-c.Continue()
+		if state.Err != nil {
+			t.Fatalf("Unexpected error: %v", state.Err)
+		}
+
+		state, err = c.Step()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if state.CurrentThread.Line != 26 {
+			t.Fatalf("Expected line 26, got %s:%d", state.CurrentThread.File, state.CurrentThread.Line)
+		}
+	})
+}
+
+func TestClientServer_stepIntoFunction(t *testing.T) {
+	withTestClient("testprog", t, func(c service.Client) {
+		fp := testProgPath(t, "testprog")
+		_, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 20})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		state := <-c.Continue()
+		if state.Err != nil {
+			t.Fatalf("Unexpected error: %v", state.Err)
+		}
+
+		state, err = c.Step()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		th := state.CurrentThread
+		if th.Function == nil || th.Function.Name != "main.helloworld" || th.Line != 10 {
+			t.Fatalf("Not stopped after the prologue of main.helloworld: %#v", th)
+		}
+	})
+}
+
 type nextTest struct {
 	begin, end int
 }
@@ -728,3 +906,432 @@ func TestClientServer_FullStacktrace(t *testing.T) {
 		}
 	})
 }
+
+func TestClientServer_watchpoint(t *testing.T) {
+	withTestClient("watchpointtest", t, func(c service.Client) {
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.main", Line: -1})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		state := <-c.Continue()
+		if state.Err != nil {
+			t.Fatalf("Unexpected error: %v", state.Err)
+		}
+		if _, err := c.ClearBreakpoint(bp.ID); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		wp, err := c.CreateWatchpoint(api.EvalScope{-1, 0}, "main.counter", "write")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		wps, err := c.ListWatchpoints()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(wps) != 1 || wps[0].ID != wp.ID {
+			t.Fatalf("Wrong watchpoint list: %v", wps)
+		}
+
+		state = <-c.Continue()
+		if state.Err != nil {
+			t.Fatalf("Unexpected error: %v", state.Err)
+		}
+		if state.Watchpoint == nil || state.Watchpoint.ID != wp.ID {
+			t.Fatalf("Not stopped at watchpoint: %#v", state.Watchpoint)
+		}
+		if state.Watchpoint.OldValue != "0" || state.Watchpoint.Value != "1" {
+			t.Fatalf("Wrong values: %s -> %s", state.Watchpoint.OldValue, state.Watchpoint.Value)
+		}
+
+		if _, err := c.ClearWatchpoint(wp.ID); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	})
+}
+
+func TestClientServer_disableBreakpoint(t *testing.T) {
+	withTestClient("watchpointtest", t, func(c service.Client) {
+		fp := testProgPath(t, "watchpointtest")
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 9})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		state := <-c.Continue()
+		if state.Err != nil {
+			t.Fatalf("Unexpected error: %v", state.Err)
+		}
+
+		bp.Disabled = true
+		bp.IgnoreCount = 1
+		if err := c.AmendBreakpoint(bp); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		bps, err := c.ListBreakpoints()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(bps) != 1 || bps[0].ID != bp.ID || !bps[0].Disabled || bps[0].IgnoreCount != 1 {
+			t.Fatalf("Breakpoint not amended: %#v", bps)
+		}
+
+		state = <-c.Continue()
+		if !state.Exited {
+			t.Fatalf("Stopped at disabled breakpoint: %#v", state.Breakpoint)
+		}
+	})
+}
+
+func TestClientServer_unrecoveredPanic(t *testing.T) {
+	withTestClient("panic", t, func(c service.Client) {
+		state := <-c.Continue()
+		if state.Err != nil {
+			t.Fatalf("Unexpected error: %v", state.Err)
+		}
+		if state.Exited || state.Breakpoint == nil || state.Breakpoint.Name != proc.UnrecoveredPanic {
+			t.Fatalf("Did not stop on unrecovered panic: %#v", state.Breakpoint)
+		}
+		if state.BreakpointInfo == nil || state.BreakpointInfo.Panic == nil {
+			t.Fatal("No panic value")
+		}
+		if v := state.BreakpointInfo.Panic.Value; !strings.Contains(v, "BOOM!") {
+			t.Fatalf("Wrong panic value: %s", v)
+		}
+	})
+}
+
+func TestClientServer_disablePanicBreakpoints(t *testing.T) {
+	withTestClient("panic", t, func(c service.Client) {
+		bps, err := c.ListPanicBreakpoints()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(bps) == 0 {
+			t.Fatal("No panic breakpoints")
+		}
+		for _, bp := range bps {
+			if bp.ID >= 0 || bp.Name == "" {
+				t.Fatalf("Not a panic breakpoint: %#v", bp)
+			}
+		}
+		n := len(bps)
+		if bps, err = c.SetPanicBreakpoints(false); err != nil || len(bps) != 0 {
+			t.Fatalf("Panic breakpoints not cleared: %v %v", bps, err)
+		}
+		if bps, err = c.SetPanicBreakpoints(true); err != nil || len(bps) != n {
+			t.Fatalf("Panic breakpoints not set again: %v %v", bps, err)
+		}
+		if bps, err = c.SetPanicBreakpoints(false); err != nil || len(bps) != 0 {
+			t.Fatalf("Panic breakpoints not cleared: %v %v", bps, err)
+		}
+		state := <-c.Continue()
+		if !state.Exited {
+			t.Fatalf("Stopped with panic breakpoints cleared: %#v", state.Breakpoint)
+		}
+	})
+}
+
+func TestClientServer_breakpointCommands(t *testing.T) {
+	withTestClient("watchpointtest", t, func(c service.Client) {
+		fp := testProgPath(t, "watchpointtest")
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 9, Commands: []string{"print counter"}})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		bp.Commands = append(bp.Commands, "stack 2")
+		if err := c.AmendBreakpoint(bp); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		state := <-c.Continue()
+		if state.Err != nil {
+			t.Fatalf("Unexpected error: %v", state.Err)
+		}
+		if state.Breakpoint == nil || !reflect.DeepEqual(state.Breakpoint.Commands, []string{"print counter", "stack 2"}) {
+			t.Fatalf("Wrong breakpoint commands: %#v", state.Breakpoint)
+		}
+	})
+}
+
+func TestClientServer_logpoint(t *testing.T) {
+	withTestClient("watchpointtest", t, func(c service.Client) {
+		fp := testProgPath(t, "watchpointtest")
+		if _, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 9, Tracepoint: true, LogMessage: "counter {counter"}); err == nil {
+			t.Fatal("Logpoint with unterminated expression created")
+		}
+		_, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 9, Tracepoint: true, LogMessage: "counter is {counter} {{x}}"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var msgs []string
+		for state := range c.Continue() {
+			if state.Exited {
+				break
+			}
+			if state.Err != nil {
+				t.Fatalf("Unexpected error: %v", state.Err)
+			}
+			if state.BreakpointInfo == nil {
+				t.Fatalf("No breakpoint info: %#v", state)
+			}
+			msgs = append(msgs, state.BreakpointInfo.LogMessage)
+		}
+		expected := []string{"counter is 0 {x}", "counter is 1 {x}", "counter is 3 {x}"}
+		if !reflect.DeepEqual(msgs, expected) {
+			t.Fatalf("Wrong messages %q, expected %q", msgs, expected)
+		}
+	})
+}
+
+func TestClientServer_runTo(t *testing.T) {
+	withTestClient("watchpointtest", t, func(c service.Client) {
+		fp := testProgPath(t, "watchpointtest")
+		state, err := c.RunTo(fmt.Sprintf("%s:%d", fp, 11))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if state.CurrentThread.Line != 11 || state.Breakpoint != nil {
+			t.Fatalf("Not stopped at line 11: %s:%d %#v", state.CurrentThread.File, state.CurrentThread.Line, state.Breakpoint)
+		}
+		bps, err := c.ListBreakpoints()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(bps) != 0 {
+			t.Fatalf("Breakpoints left behind: %#v", bps)
+		}
+	})
+}
+
+func TestClientServer_runToHonorsBreakpoints(t *testing.T) {
+	withTestClient("watchpointtest", t, func(c service.Client) {
+		fp := testProgPath(t, "watchpointtest")
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 9})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		state, err := c.RunTo(fmt.Sprintf("%s:%d", fp, 11))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if state.Breakpoint == nil || state.Breakpoint.ID != bp.ID {
+			t.Fatalf("Not stopped at breakpoint: %#v", state.Breakpoint)
+		}
+		// The temporary breakpoint at line 11 must be gone.
+		if _, err := c.ClearBreakpoint(bp.ID); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		state = <-c.Continue()
+		if !state.Exited {
+			t.Fatalf("Stopped at %s:%d", state.CurrentThread.File, state.CurrentThread.Line)
+		}
+	})
+}
+
+func TestClientServer_runToSkippedBreakpoint(t *testing.T) {
+	withTestClient("watchpointtest", t, func(c service.Client) {
+		fp := testProgPath(t, "watchpointtest")
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 9, IgnoreCount: 10})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		state, err := c.RunTo(fmt.Sprintf("%s:%d", fp, 9))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if state.Exited || state.CurrentThread.Line != 9 {
+			t.Fatalf("Did not stop at line 9: %#v", state.CurrentThread)
+		}
+		bps, err := c.ListBreakpoints()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(bps) != 1 || bps[0].ID != bp.ID || bps[0].TotalHitCount != 1 {
+			t.Fatalf("Breakpoint not hit once: %#v", bps)
+		}
+		// The breakpoint is still ignored once the target is reached.
+		state = <-c.Continue()
+		if !state.Exited {
+			t.Fatalf("Stopped at %s:%d", state.CurrentThread.File, state.CurrentThread.Line)
+		}
+	})
+}
+
+func TestClientServer_runPastLine(t *testing.T) {
+	withTestClient("watchpointtest", t, func(c service.Client) {
+		fp := testProgPath(t, "watchpointtest")
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 9})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		state := <-c.Continue()
+		if state.Err != nil {
+			t.Fatalf("Unexpected error: %v", state.Err)
+		}
+		if _, err := c.ClearBreakpoint(bp.ID); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		state, err = c.RunTo("")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if state.CurrentThread.Line != 11 {
+			t.Fatalf("Did not exit the loop: %s:%d", state.CurrentThread.File, state.CurrentThread.Line)
+		}
+	})
+}
+
+func TestClientServer_call(t *testing.T) {
+	withTestClient("fncall", t, func(c service.Client) {
+		fp := testProgPath(t, "fncall")
+		_, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 33})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		state := <-c.Continue()
+		if state.Err != nil {
+			t.Fatalf("Unexpected error: %v", state.Err)
+		}
+
+		for _, tc := range []struct {
+			expr  string
+			value string
+		}{
+			{"add(x, 3)", "43"},
+			{"main.add(-1, 1)", "0"},
+			{"scale(2, true)", "-4"},
+			{"p.sum()", "3"},
+			{"pp.sum()", "7"},
+			{"pp.move(2)", "5"},
+			{"p.move(1)", "2"},
+		} {
+			vals, err := c.Call(tc.expr)
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", tc.expr, err)
+			}
+			if len(vals) != 1 || vals[0].Value != tc.value {
+				t.Fatalf("%s: expected %s got %#v", tc.expr, tc.value, vals)
+			}
+		}
+
+		if _, err := c.Call("add(1)"); err == nil {
+			t.Fatal("expected error calling add with one argument")
+		}
+
+		// The process must continue normally after the calls.
+		state, err = c.GetState()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if state.CurrentThread.Line != 33 {
+			t.Fatalf("Not at line 33 after the calls: %d", state.CurrentThread.Line)
+		}
+		state = <-c.Continue()
+		if !state.Exited {
+			t.Fatalf("Expected process to exit: %#v", state)
+		}
+	})
+}
+
+func TestClientServer_jump(t *testing.T) {
+	withTestClient("watchpointtest", t, func(c service.Client) {
+		fp := testProgPath(t, "watchpointtest")
+		_, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 11})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		state := <-c.Continue()
+		if state.Err != nil {
+			t.Fatalf("Unexpected error: %v", state.Err)
+		}
+
+		if _, err := c.Jump("fmt.Println"); err == nil {
+			t.Fatal("expected error jumping out of the current function")
+		}
+
+		state, err = c.Jump(fmt.Sprintf("%s:%d", fp, 9))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if state.CurrentThread.Line != 9 {
+			t.Fatalf("Not at line 9 after jump: %d", state.CurrentThread.Line)
+		}
+
+		// The loop body runs once more with i == 3.
+		state = <-c.Continue()
+		if state.Err != nil {
+			t.Fatalf("Unexpected error: %v", state.Err)
+		}
+		if state.CurrentThread.Line != 11 {
+			t.Fatalf("Not back at line 11: %d", state.CurrentThread.Line)
+		}
+		v, err := c.EvalVariable(api.EvalScope{-1, 0}, "main.counter")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if v.Value != "10" {
+			t.Fatalf("Wrong value of counter: %s", v.Value)
+		}
+	})
+}
+
+func TestClientServer_checkpoint(t *testing.T) {
+	withTestClient("watchpointtest", t, func(c service.Client) {
+		fp := testProgPath(t, "watchpointtest")
+		_, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 9})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		state := <-c.Continue()
+		if state.Err != nil {
+			t.Fatalf("Unexpected error: %v", state.Err)
+		}
+		id, err := c.Checkpoint("")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		cps, err := c.ListCheckpoints()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(cps) != 1 || cps[0].ID != id || !strings.Contains(cps[0].Where, "watchpointtest.go:9") {
+			t.Fatalf("Wrong checkpoints: %#v", cps)
+		}
+
+		counter := func() string {
+			v, err := c.EvalVariable(api.EvalScope{-1, 0}, "main.counter")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			return v.Value
+		}
+
+		// The checkpoint can be restarted more than once, also after the
+		// process has exited.
+		for i := 0; i < 2; i++ {
+			for {
+				state = <-c.Continue()
+				if state.Err != nil || state.Exited {
+					break
+				}
+			}
+			if err := c.RestartCheckpoint(id); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			state, err = c.GetState()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if state.CurrentThread.Line != 9 || counter() != "0" {
+				t.Fatalf("Wrong state after restarting checkpoint: line %d counter %s", state.CurrentThread.Line, counter())
+			}
+		}
+
+		state = <-c.Continue()
+		if state.Err != nil {
+			t.Fatalf("Unexpected error: %v", state.Err)
+		}
+		if state.CurrentThread.Line != 9 || counter() != "1" {
+			t.Fatalf("Wrong state after continuing: line %d counter %s", state.CurrentThread.Line, counter())
+		}
+	})
+}
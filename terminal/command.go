@@ -13,6 +13,7 @@ import (
 	"strings"
 	"text/tabwriter"
 
+	"github.com/derekparker/delve/config"
 	"github.com/derekparker/delve/service"
 	"github.com/derekparker/delve/service/api"
 	"github.com/derekparker/delve/service/debugger"
@@ -52,21 +53,40 @@ func DebugCommands(client service.Client) *Commands {
 
 	c.cmds = []command{
 		{aliases: []string{"help"}, cmdFn: c.help, helpMsg: "Prints the help message."},
-		{aliases: []string{"break", "b"}, cmdFn: breakpoint, helpMsg: "break <linespec> [-stack <n>|-goroutine|<variable name>]*"},
+		{aliases: []string{"break", "b"}, cmdFn: breakpoint, helpMsg: "break <linespec> [-stack <n>|-goroutine|-ignore <n>|-every <n>|-gid <n>|-created <linespec>|<variable name>]* [if <condition>]. -gid and -created restrict the breakpoint to a goroutine, or to goroutines started by the go statement at linespec"},
 		{aliases: []string{"trace", "t"}, cmdFn: tracepoint, helpMsg: "Set tracepoint, takes the same arguments as break."},
-		{aliases: []string{"restart", "r"}, cmdFn: restart, helpMsg: "Restart process."},
+		{aliases: []string{"logpoint", "lp"}, cmdFn: logpoint, helpMsg: "logpoint <linespec> \"<message>\". Set logpoint, it prints message every time it is hit without stopping. Expressions between braces in message, like \"retry {n}\", are evaluated in the scope of the goroutine hitting the logpoint."},
+		{aliases: []string{"watch"}, cmdFn: watch, helpMsg: "watch [-r|-rw] <expression>. Stop when the value of expression is written (or read with -r, read or written with -rw)."},
+		{aliases: []string{"handle"}, cmdFn: handle, helpMsg: "handle [<signal> [<action>...]]. Prints or changes what happens when the program receives a signal, actions are stop, nostop, print, noprint, pass, nopass, ignore and noignore."},
+		{aliases: []string{"panic-breakpoints"}, cmdFn: panicBreakpoints, helpMsg: "panic-breakpoints [on|off]. Prints, sets or clears the breakpoints stopping the program when it does not recover from a panic or has a fatal error. They are set when the program starts, unless delve is started with --panic-breakpoints=false."},
+		{aliases: []string{"catch"}, cmdFn: catch, helpMsg: "catch syscall [<name>...]. Stop when the program enters or returns from the named system calls, like openat or connect, or from any system call if no name is given (linux only)."},
+		{aliases: []string{"restart", "r"}, cmdFn: restart, helpMsg: "restart [-rebuild | <checkpoint-id>]. Restart process, with -rebuild the program is compiled again first (debug and test only). With a checkpoint ID the process goes back to the state saved by the checkpoint, breakpoints are kept."},
+		{aliases: []string{"checkpoint", "check"}, cmdFn: checkpoint, helpMsg: "checkpoint [<where>]. Saves the state of the process so that it can be restarted later with restart <checkpoint-id> (linux only)."},
+		{aliases: []string{"checkpoints"}, cmdFn: checkpoints, helpMsg: "Print out info for existing checkpoints."},
 		{aliases: []string{"continue", "c"}, cmdFn: cont, helpMsg: "Run until breakpoint or program termination."},
-		{aliases: []string{"step", "si"}, cmdFn: step, helpMsg: "Single step through program."},
+		{aliases: []string{"step", "s"}, cmdFn: step, helpMsg: "Continue to the next source line, entering function calls."},
+		{aliases: []string{"stepi", "si"}, cmdFn: stepInstruction, helpMsg: "Single step a single cpu instruction."},
 		{aliases: []string{"next", "n"}, cmdFn: next, helpMsg: "Step over to next source line."},
+		{aliases: []string{"until", "u"}, cmdFn: until, helpMsg: "until [<linespec>]. Continue until linespec is reached, breakpoints still stop the process. Without linespec continue until a line past the current one, in the current function, to exit a loop."},
+		{aliases: []string{"jump", "j"}, cmdFn: jump, helpMsg: "jump <linespec>. Moves execution to linespec in the current function without running the code in between, for example to run a block again after changing a variable with set."},
+		{aliases: []string{"stepout", "finish"}, cmdFn: stepout, helpMsg: "Step out of the current function and print its return values."},
 		{aliases: []string{"threads"}, cmdFn: threads, helpMsg: "Print out info for every traced thread."},
 		{aliases: []string{"thread", "tr"}, cmdFn: thread, helpMsg: "Switch to the specified thread."},
 		{aliases: []string{"clear"}, cmdFn: clear, helpMsg: "Deletes breakpoint."},
 		{aliases: []string{"clearall"}, cmdFn: clearAll, helpMsg: "Deletes all breakpoints."},
+		{aliases: []string{"enable"}, cmdFn: enable, helpMsg: "enable [<id>|<id>-<id>]*. Enables breakpoints, all of them if no ID is given."},
+		{aliases: []string{"disable"}, cmdFn: disable, helpMsg: "disable [<id>|<id>-<id>]*. Disables breakpoints without deleting them, all of them if no ID is given."},
+		{aliases: []string{"on"}, cmdFn: on, helpMsg: "on <id> <command>. Runs command every time breakpoint id stops the process, commands are run in the order they were added. Without a command removes all the commands of the breakpoint."},
 		{aliases: []string{"goroutines"}, cmdFn: goroutines, helpMsg: "Print out info for every goroutine."},
 		{aliases: []string{"goroutine"}, cmdFn: goroutine, helpMsg: "Sets current goroutine."},
+		{aliases: []string{"processes"}, cmdFn: processes, helpMsg: "Print out the processes being debugged, children are added when they fork with --follow-fork=both."},
+		{aliases: []string{"process"}, cmdFn: process, helpMsg: "process <pid>. Sets current process."},
 		{aliases: []string{"breakpoints", "bp"}, cmdFn: breakpoints, helpMsg: "Print out info for active breakpoints."},
-		{aliases: []string{"print", "p"}, cmdFn: g0f0(printVar), helpMsg: "Evaluate a variable."},
+		{aliases: []string{"save-breakpoints"}, cmdFn: saveBreakpoints, helpMsg: "save-breakpoints [<file>]. Saves breakpoints to file, by default to the project breakpoints file .dlv/breakpoints.yml in the working directory, which is loaded when the terminal starts, also when attaching to a process or connecting to a headless server."},
+		{aliases: []string{"load-breakpoints"}, cmdFn: loadBreakpoints, helpMsg: "load-breakpoints [<file>]. Sets the breakpoints saved in file, by default in the project breakpoints file."},
+		{aliases: []string{"print", "p"}, cmdFn: g0f0(printVar), helpMsg: "Evaluate an expression."},
 		{aliases: []string{"set"}, cmdFn: g0f0(setVar), helpMsg: "Changes the value of a variable."},
+		{aliases: []string{"call"}, cmdFn: callFunction, helpMsg: "call <function>(<args>). Calls a function of the program on the current goroutine, breakpoints and watchpoints are ignored during the call. The call fails if the program stops at a syscall catchpoint or because of a signal before the function returns."},
 		{aliases: []string{"sources"}, cmdFn: filterSortAndOutput(sources), helpMsg: "Print list of source files, optionally filtered by a regexp."},
 		{aliases: []string{"funcs"}, cmdFn: filterSortAndOutput(funcs), helpMsg: "Print list of functions, optionally filtered by a regexp."},
 		{aliases: []string{"args"}, cmdFn: filterSortAndOutput(g0f0filter(args)), helpMsg: "Print function arguments, optionally filtered by a regexp."},
@@ -242,6 +262,45 @@ func goroutines(t *Term, args ...string) error {
 	return nil
 }
 
+func processes(t *Term, args ...string) error {
+	procs, err := t.client.ListProcesses()
+	if err != nil {
+		return err
+	}
+	for _, p := range procs {
+		prefix := "  "
+		if p.Current {
+			prefix = "* "
+		}
+		status := ""
+		if p.Exited {
+			status = " (exited)"
+		}
+		fmt.Printf("%sProcess %d%s\n", prefix, p.Pid, status)
+	}
+	return nil
+}
+
+func process(t *Term, args ...string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("you must specify a process")
+	}
+	pid, err := strconv.Atoi(args[0])
+	if err != nil {
+		return err
+	}
+	oldState, err := t.client.GetState()
+	if err != nil {
+		return err
+	}
+	newState, err := t.client.SwitchProcess(pid)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Switched from process %d to %d\n", oldState.Pid, newState.Pid)
+	return printcontext(t, newState)
+}
+
 func goroutine(t *Term, args ...string) error {
 	switch len(args) {
 	case 0:
@@ -377,22 +436,82 @@ func formatGoroutine(g *api.Goroutine) string {
 }
 
 func restart(t *Term, args ...string) error {
-	if err := t.client.Restart(); err != nil {
+	var (
+		discarded []api.DiscardedBreakpoint
+		err       error
+	)
+	switch {
+	case len(args) == 0:
+		discarded, err = t.client.Restart()
+	case args[0] == "-rebuild":
+		discarded, err = t.client.Rebuild()
+	default:
+		id, perr := strconv.Atoi(args[0])
+		if perr != nil {
+			return fmt.Errorf("unknown argument %q", args[0])
+		}
+		return restartCheckpoint(t, id)
+	}
+	if err != nil {
 		return err
 	}
 	fmt.Println("Process restarted with PID", t.client.ProcessPid())
+	for _, d := range discarded {
+		if wp := d.Watchpoint; wp != nil {
+			fmt.Printf("Discarded watchpoint %d on %s: %s\n", wp.ID, wp.Expr, d.Reason)
+			continue
+		}
+		bp := d.Breakpoint
+		fmt.Printf("Discarded breakpoint %d at %s:%d: %s\n", bp.ID, shortenFilePath(bp.File), bp.Line, d.Reason)
+	}
+	return nil
+}
+
+func restartCheckpoint(t *Term, id int) error {
+	if err := t.client.RestartCheckpoint(id); err != nil {
+		return err
+	}
+	fmt.Printf("Process restarted from checkpoint %d with PID %d\n", id, t.client.ProcessPid())
+	state, err := t.client.GetState()
+	if err != nil {
+		return err
+	}
+	printcontext(t, state)
+	return nil
+}
+
+func checkpoint(t *Term, args ...string) error {
+	id, err := t.client.Checkpoint(strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Checkpoint %d created.\n", id)
+	return nil
+}
+
+func checkpoints(t *Term, args ...string) error {
+	cps, err := t.client.ListCheckpoints()
+	if err != nil {
+		return err
+	}
+	for _, cp := range cps {
+		fmt.Printf("(%d) %#x %s\n", cp.ID, cp.PC, cp.Where)
+	}
 	return nil
 }
 
 func cont(t *Term, args ...string) error {
+	var state *api.DebuggerState
 	stateChan := t.client.Continue()
-	for state := range stateChan {
+	for state = range stateChan {
 		if state.Err != nil {
+			printSignals(state)
 			return state.Err
 		}
 		printcontext(t, state)
 	}
-	return nil
+	// The process is stopped only after the channel is closed.
+	return runBreakpointCommands(t, state)
 }
 
 func step(t *Term, args ...string) error {
@@ -401,7 +520,7 @@ func step(t *Term, args ...string) error {
 		return err
 	}
 	printcontext(t, state)
-	return nil
+	return runBreakpointCommands(t, state)
 }
 
 func next(t *Term, args ...string) error {
@@ -410,9 +529,51 @@ func next(t *Term, args ...string) error {
 		return err
 	}
 	printcontext(t, state)
+	return runBreakpointCommands(t, state)
+}
+
+func until(t *Term, args ...string) error {
+	state, err := t.client.RunTo(strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+	if state.Err != nil {
+		return state.Err
+	}
+	printcontext(t, state)
+	return runBreakpointCommands(t, state)
+}
+
+func jump(t *Term, args ...string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("not enough arguments")
+	}
+	state, err := t.client.Jump(strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+	printcontext(t, state)
 	return nil
 }
 
+func stepInstruction(t *Term, args ...string) error {
+	state, err := t.client.StepInstruction()
+	if err != nil {
+		return err
+	}
+	printcontext(t, state)
+	return runBreakpointCommands(t, state)
+}
+
+func stepout(t *Term, args ...string) error {
+	state, err := t.client.StepOut()
+	if err != nil {
+		return err
+	}
+	printcontext(t, state)
+	return runBreakpointCommands(t, state)
+}
+
 func clear(t *Term, args ...string) error {
 	if len(args) == 0 {
 		return fmt.Errorf("not enough arguments")
@@ -421,6 +582,32 @@ func clear(t *Term, args ...string) error {
 	if err != nil {
 		return err
 	}
+	watchPoints, err := t.client.ListWatchpoints()
+	if err != nil {
+		return err
+	}
+	for _, wp := range watchPoints {
+		if wp.ID == id {
+			if _, err := t.client.ClearWatchpoint(id); err != nil {
+				return err
+			}
+			fmt.Printf("Watchpoint %d cleared on %s\n", wp.ID, wp.Expr)
+			return nil
+		}
+	}
+	catchPoints, err := t.client.ListSyscallCatchpoints()
+	if err != nil {
+		return err
+	}
+	for _, cp := range catchPoints {
+		if cp.ID == id {
+			if _, err := t.client.ClearSyscallCatchpoint(id); err != nil {
+				return err
+			}
+			fmt.Printf("Catchpoint %d cleared on %s\n", cp.ID, catchpointSyscalls(cp))
+			return nil
+		}
+	}
 	bp, err := t.client.ClearBreakpoint(id)
 	if err != nil {
 		return err
@@ -441,27 +628,224 @@ func clearAll(t *Term, args ...string) error {
 		}
 		fmt.Printf("Breakpoint %d cleared at %#v for %s %s:%d\n", bp.ID, bp.Addr, bp.FunctionName, shortenFilePath(bp.File), bp.Line)
 	}
+
+	watchPoints, err := t.client.ListWatchpoints()
+	if err != nil {
+		return err
+	}
+	for _, wp := range watchPoints {
+		if _, err := t.client.ClearWatchpoint(wp.ID); err != nil {
+			fmt.Printf("Couldn't delete watchpoint %d on %s: %s\n", wp.ID, wp.Expr, err)
+			continue
+		}
+		fmt.Printf("Watchpoint %d cleared on %s\n", wp.ID, wp.Expr)
+	}
+
+	catchPoints, err := t.client.ListSyscallCatchpoints()
+	if err != nil {
+		return err
+	}
+	for _, cp := range catchPoints {
+		if _, err := t.client.ClearSyscallCatchpoint(cp.ID); err != nil {
+			fmt.Printf("Couldn't delete catchpoint %d on %s: %s\n", cp.ID, catchpointSyscalls(cp), err)
+			continue
+		}
+		fmt.Printf("Catchpoint %d cleared on %s\n", cp.ID, catchpointSyscalls(cp))
+	}
 	return nil
 }
 
+func enable(t *Term, args ...string) error {
+	return setBreakpointsEnabled(t, true, args...)
+}
+
+func disable(t *Term, args ...string) error {
+	return setBreakpointsEnabled(t, false, args...)
+}
+
+func setBreakpointsEnabled(t *Term, enabled bool, args ...string) error {
+	breakPoints, err := t.client.ListBreakpoints()
+	if err != nil {
+		return err
+	}
+	sort.Sort(ById(breakPoints))
+	selected := breakPoints
+	if len(args) > 0 {
+		ids, err := parseIDRanges(args)
+		if err != nil {
+			return err
+		}
+		byID := make(map[int]*api.Breakpoint, len(breakPoints))
+		for _, bp := range breakPoints {
+			byID[bp.ID] = bp
+		}
+		selected = make([]*api.Breakpoint, 0, len(ids))
+		for _, id := range ids {
+			bp, ok := byID[id]
+			if !ok {
+				return fmt.Errorf("no breakpoint with id %d", id)
+			}
+			selected = append(selected, bp)
+		}
+	}
+	what := "disabled"
+	if enabled {
+		what = "enabled"
+	}
+	for _, bp := range selected {
+		bp.Disabled = !enabled
+		if err := t.client.AmendBreakpoint(bp); err != nil {
+			return err
+		}
+		fmt.Printf("Breakpoint %d %s at %#v for %s %s:%d\n", bp.ID, what, bp.Addr, bp.FunctionName, shortenFilePath(bp.File), bp.Line)
+	}
+	return nil
+}
+
+// Parses a list of IDs and inclusive ID ranges, like "1 3-5".
+func parseIDRanges(args []string) ([]int, error) {
+	var ids []int
+	for _, arg := range args {
+		lo, hi := arg, arg
+		if i := strings.Index(arg, "-"); i > 0 {
+			lo, hi = arg[:i], arg[i+1:]
+		}
+		first, err := strconv.Atoi(lo)
+		if err != nil {
+			return nil, fmt.Errorf("invalid breakpoint id %q", arg)
+		}
+		last, err := strconv.Atoi(hi)
+		if err != nil || last < first {
+			return nil, fmt.Errorf("invalid breakpoint id range %q", arg)
+		}
+		for id := first; id <= last; id++ {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func on(t *Term, args ...string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("not enough arguments")
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid breakpoint id %q", args[0])
+	}
+	bp, err := t.client.GetBreakpoint(id)
+	if err != nil {
+		return err
+	}
+	cmd := strings.TrimSpace(strings.Join(args[1:], " "))
+	if cmd == "" {
+		bp.Commands = nil
+	} else {
+		bp.Commands = append(bp.Commands, cmd)
+	}
+	return t.client.AmendBreakpoint(bp)
+}
+
+// Commands of a breakpoint being run by runBreakpointCommands.
+type breakpointCommands struct {
+	// Set when one of the commands resumed the process, which then
+	// stopped with state.
+	resumed bool
+	state   *api.DebuggerState
+}
+
+// Runs the commands of the breakpoint that stopped the process, if any.
+// A command resuming the process, like continue, ends the commands of
+// the breakpoint, the ones of the breakpoint it stops at run next.
+// Called by a command run by a breakpoint, it only records the new stop.
+func runBreakpointCommands(t *Term, state *api.DebuggerState) error {
+	if t.cmds == nil {
+		return nil
+	}
+	if t.bpcmds != nil {
+		t.bpcmds.resumed = true
+		t.bpcmds.state = state
+		return nil
+	}
+	// Pressing enter repeats the last command typed, not the last one
+	// run by a breakpoint.
+	lastCmd := t.cmds.lastCmd
+	defer func() {
+		t.cmds.lastCmd = lastCmd
+		t.bpcmds = nil
+	}()
+	for state != nil && state.Breakpoint != nil {
+		bp := state.Breakpoint
+		state = nil
+		for _, cmdstr := range bp.Commands {
+			fmt.Printf("(on %d) %s\n", bp.ID, cmdstr)
+			cmdstr, args := parseCommand(cmdstr)
+			t.bpcmds = &breakpointCommands{}
+			if err := t.cmds.Find(cmdstr)(t, args...); err != nil {
+				return err
+			}
+			if t.bpcmds.resumed {
+				state = t.bpcmds.state
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func breakpointKind(bp *api.Breakpoint) string {
+	switch {
+	case bp.LogMessage != "":
+		return "Logpoint"
+	case bp.Tracepoint:
+		return "Tracepoint"
+	}
+	return "Breakpoint"
+}
+
 type ById []*api.Breakpoint
 
 func (a ById) Len() int           { return len(a) }
 func (a ById) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 func (a ById) Less(i, j int) bool { return a[i].ID < a[j].ID }
 
+type watchpointsByID []*api.Watchpoint
+
+func (a watchpointsByID) Len() int           { return len(a) }
+func (a watchpointsByID) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a watchpointsByID) Less(i, j int) bool { return a[i].ID < a[j].ID }
+
+type catchpointsByID []*api.SyscallCatchpoint
+
+func (a catchpointsByID) Len() int           { return len(a) }
+func (a catchpointsByID) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a catchpointsByID) Less(i, j int) bool { return a[i].ID < a[j].ID }
+
 func breakpoints(t *Term, args ...string) error {
 	breakPoints, err := t.client.ListBreakpoints()
 	if err != nil {
 		return err
 	}
+	watchPoints, err := t.client.ListWatchpoints()
+	if err != nil {
+		return err
+	}
+	catchPoints, err := t.client.ListSyscallCatchpoints()
+	if err != nil {
+		return err
+	}
+	panicBps, err := t.client.ListPanicBreakpoints()
+	if err != nil {
+		return err
+	}
 	sort.Sort(ById(breakPoints))
 	for _, bp := range breakPoints {
-		thing := "Breakpoint"
-		if bp.Tracepoint {
-			thing = "Tracepoint"
+		thing := breakpointKind(bp)
+		state := ""
+		if bp.Disabled {
+			state = " [disabled]"
 		}
-		fmt.Printf("%s %d at %#v %s:%d\n", thing, bp.ID, bp.Addr, shortenFilePath(bp.File), bp.Line)
+		fmt.Printf("%s %d%s at %#v %s:%d (%d)\n", thing, bp.ID, state, bp.Addr, shortenFilePath(bp.File), bp.Line, bp.TotalHitCount)
 
 		var attrs []string
 		if bp.Stacktrace > 0 {
@@ -471,24 +855,208 @@ func breakpoints(t *Term, args ...string) error {
 		if bp.Goroutine {
 			attrs = append(attrs, "-goroutine")
 		}
+		if bp.IgnoreCount > 0 {
+			attrs = append(attrs, "-ignore", strconv.FormatUint(bp.IgnoreCount, 10))
+		}
+		if bp.StopEvery > 0 {
+			attrs = append(attrs, "-every", strconv.FormatUint(bp.StopEvery, 10))
+		}
+		if bp.GoroutineID > 0 {
+			attrs = append(attrs, "-gid", strconv.Itoa(bp.GoroutineID))
+		}
+		if bp.CreatedAt != "" {
+			attrs = append(attrs, "-created", bp.CreatedAt)
+		}
 		for i := range bp.Variables {
 			attrs = append(attrs, bp.Variables[i])
 		}
+		if bp.LogMessage != "" {
+			attrs = append(attrs, strconv.Quote(bp.LogMessage))
+		}
+		if bp.Cond != "" {
+			attrs = append(attrs, "if", bp.Cond)
+		}
 		if len(attrs) > 0 {
 			fmt.Printf("\t%s\n", strings.Join(attrs, " "))
 		}
+		for _, cmd := range bp.Commands {
+			fmt.Printf("\ton: %s\n", cmd)
+		}
+
+		gids := make([]int, 0, len(bp.HitCount))
+		for gid := range bp.HitCount {
+			if n, err := strconv.Atoi(gid); err == nil {
+				gids = append(gids, n)
+			}
+		}
+		sort.Ints(gids)
+		for _, gid := range gids {
+			fmt.Printf("\tgoroutine %d: %d hits\n", gid, bp.HitCount[strconv.Itoa(gid)])
+		}
+	}
+	sort.Sort(watchpointsByID(watchPoints))
+	for _, wp := range watchPoints {
+		fmt.Printf("%s %d on %s (%s) at %#v (%d)\n", watchpointKind(wp), wp.ID, wp.Expr, wp.Type, wp.Addr, wp.TotalHitCount)
+	}
+	sort.Sort(catchpointsByID(catchPoints))
+	for _, cp := range catchPoints {
+		fmt.Printf("Catchpoint %d on %s (%d)\n", cp.ID, catchpointSyscalls(cp), cp.TotalHitCount)
+	}
+	printPanicBreakpoints(panicBps)
+	return nil
+}
+
+func panicBreakpoints(t *Term, args ...string) error {
+	var (
+		bps []*api.Breakpoint
+		err error
+	)
+	switch {
+	case len(args) == 0:
+		bps, err = t.client.ListPanicBreakpoints()
+	case len(args) == 1 && (args[0] == "on" || args[0] == "off"):
+		bps, err = t.client.SetPanicBreakpoints(args[0] == "on")
+	default:
+		return fmt.Errorf("usage: panic-breakpoints [on|off]")
 	}
+	if err != nil {
+		return err
+	}
+	if len(bps) == 0 {
+		fmt.Println("No panic breakpoints")
+	}
+	printPanicBreakpoints(bps)
 	return nil
 }
 
+func printPanicBreakpoints(bps []*api.Breakpoint) {
+	sort.Sort(ById(bps))
+	for _, bp := range bps {
+		fmt.Printf("Panic breakpoint %s at %#v %s:%d (%d)\n", bp.Name, bp.Addr, shortenFilePath(bp.File), bp.Line, bp.TotalHitCount)
+	}
+}
+
+func watch(t *Term, args ...string) error {
+	wtype := "write"
+	if len(args) > 0 {
+		switch args[0] {
+		case "-r":
+			wtype = "read"
+			args = args[1:]
+		case "-rw":
+			wtype = "rw"
+			args = args[1:]
+		}
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("not enough arguments")
+	}
+	wp, err := t.client.CreateWatchpoint(api.EvalScope{-1, 0}, strings.Join(args, " "), wtype)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s %d set on %s (%s) at %#v\n", watchpointKind(wp), wp.ID, wp.Expr, wp.Type, wp.Addr)
+	return nil
+}
+
+func catch(t *Term, args ...string) error {
+	if len(args) == 0 || args[0] != "syscall" {
+		return fmt.Errorf("only system calls can be caught: catch syscall [<name>...]")
+	}
+	cp, err := t.client.CreateSyscallCatchpoint(args[1:])
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Catchpoint %d set on %s\n", cp.ID, catchpointSyscalls(cp))
+	return nil
+}
+
+func handle(t *Term, args ...string) error {
+	var policies []api.SignalPolicy
+	switch len(args) {
+	case 0:
+		var err error
+		policies, err = t.client.ListSignalPolicies()
+		if err != nil {
+			return err
+		}
+	default:
+		policy, err := t.client.HandleSignal(args[0], args[1:])
+		if err != nil {
+			return err
+		}
+		policies = append(policies, *policy)
+	}
+	yesno := func(b bool) string {
+		if b {
+			return "Yes"
+		}
+		return "No"
+	}
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 1, ' ', 0)
+	fmt.Fprintln(w, "Signal\tStop\tPrint\tPass")
+	for _, p := range policies {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", p.Name, yesno(p.Stop), yesno(p.Print), yesno(p.Pass))
+	}
+	return w.Flush()
+}
+
+func printSignals(state *api.DebuggerState) {
+	for _, s := range state.Signals {
+		if s.Stopped {
+			fmt.Printf("Thread %d stopped by signal %s\n", s.ThreadID, s.Name)
+		} else {
+			fmt.Printf("Thread %d received signal %s\n", s.ThreadID, s.Name)
+		}
+	}
+}
+
+func catchpointSyscalls(cp *api.SyscallCatchpoint) string {
+	if len(cp.Syscalls) == 0 {
+		return "all system calls"
+	}
+	return "syscall " + strings.Join(cp.Syscalls, " ")
+}
+
+func printSyscall(s *api.SyscallStop) {
+	args := make([]string, len(s.Args))
+	for i := range s.Args {
+		args[i] = fmt.Sprintf("%#x", s.Args[i])
+	}
+	if s.Exit {
+		fmt.Printf("Catchpoint %d (returned from syscall %s(%s) = %d)\n", s.Catchpoint, s.Name, strings.Join(args, ", "), s.Return)
+	} else {
+		fmt.Printf("Catchpoint %d (call to syscall %s(%s))\n", s.Catchpoint, s.Name, strings.Join(args, ", "))
+	}
+	if s.Stacktrace != nil {
+		fmt.Printf("\tStack:\n")
+		printStack(s.Stacktrace, "\t\t")
+	}
+}
+
+func watchpointKind(wp *api.Watchpoint) string {
+	if wp.Software {
+		return "Software watchpoint (slow)"
+	}
+	return "Watchpoint"
+}
+
 func setBreakpoint(t *Term, tracepoint bool, args ...string) error {
 	if len(args) < 1 {
 		return fmt.Errorf("address required, specify either a function name or <file:line>")
 	}
 	requestedBp := &api.Breakpoint{}
 
+argsLoop:
 	for i := 1; i < len(args); i++ {
 		switch args[i] {
+		case "if":
+			if i+1 >= len(args) {
+				return fmt.Errorf("if must be followed by a condition")
+			}
+			requestedBp.Cond = strings.Join(args[i+1:], " ")
+			break argsLoop
 		case "-stack":
 			i++
 			n, err := strconv.Atoi(args[i])
@@ -498,20 +1066,69 @@ func setBreakpoint(t *Term, tracepoint bool, args ...string) error {
 			requestedBp.Stacktrace = n
 		case "-goroutine":
 			requestedBp.Goroutine = true
+		case "-ignore", "-every":
+			if i+1 >= len(args) {
+				return fmt.Errorf("%s must be followed by a number", args[i])
+			}
+			n, err := strconv.ParseUint(args[i+1], 10, 64)
+			if err != nil {
+				return fmt.Errorf("argument of %s must be a number", args[i])
+			}
+			if args[i] == "-ignore" {
+				requestedBp.IgnoreCount = n
+			} else {
+				requestedBp.StopEvery = n
+			}
+			i++
+		case "-gid":
+			if i+1 >= len(args) {
+				return fmt.Errorf("-gid must be followed by a goroutine id")
+			}
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("argument of -gid must be a goroutine id")
+			}
+			requestedBp.GoroutineID = n
+			i++
+		case "-created":
+			if i+1 >= len(args) {
+				return fmt.Errorf("-created must be followed by a location")
+			}
+			requestedBp.CreatedAt = args[i+1]
+			i++
 		default:
 			requestedBp.Variables = append(requestedBp.Variables, args[i])
 		}
 	}
 
 	requestedBp.Tracepoint = tracepoint
-	locs, err := t.client.FindLocation(api.EvalScope{-1, 0}, args[0])
+	return createBreakpoints(t, requestedBp, args[0])
+}
+
+func logpoint(t *Term, args ...string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("not enough arguments")
+	}
+	msg := strings.Join(args[1:], " ")
+	if strings.HasPrefix(msg, "\"") {
+		var err error
+		msg, err = strconv.Unquote(msg)
+		if err != nil {
+			return fmt.Errorf("invalid message %s", strings.Join(args[1:], " "))
+		}
+	}
+	requestedBp := &api.Breakpoint{Tracepoint: true, LogMessage: msg}
+	return createBreakpoints(t, requestedBp, args[0])
+}
+
+// Creates a breakpoint with the attributes of requestedBp at every
+// location matching locspec.
+func createBreakpoints(t *Term, requestedBp *api.Breakpoint, locspec string) error {
+	locs, err := t.client.FindLocation(api.EvalScope{-1, 0}, locspec)
 	if err != nil {
 		return err
 	}
-	thing := "Breakpoint"
-	if tracepoint {
-		thing = "Tracepoint"
-	}
+	thing := breakpointKind(requestedBp)
 	for _, loc := range locs {
 		requestedBp.Addr = loc.PC
 
@@ -525,6 +1142,92 @@ func setBreakpoint(t *Term, tracepoint bool, args ...string) error {
 	return nil
 }
 
+func saveBreakpoints(t *Term, args ...string) error {
+	breakPoints, err := t.client.ListBreakpoints()
+	if err != nil {
+		return err
+	}
+	sort.Sort(ById(breakPoints))
+	saved := make([]config.SavedBreakpoint, 0, len(breakPoints))
+	for _, bp := range breakPoints {
+		saved = append(saved, config.SavedBreakpoint{
+			Location:    breakpointLocationSpec(t, bp),
+			Tracepoint:  bp.Tracepoint,
+			Stacktrace:  bp.Stacktrace,
+			Goroutine:   bp.Goroutine,
+			Variables:   bp.Variables,
+			Commands:    bp.Commands,
+			LogMessage:  bp.LogMessage,
+			Cond:        bp.Cond,
+			IgnoreCount: bp.IgnoreCount,
+			StopEvery:   bp.StopEvery,
+			CreatedAt:   bp.CreatedAt,
+			Disabled:    bp.Disabled,
+		})
+	}
+	path := config.ProjectBreakpointsFilePath()
+	if len(args) > 0 && args[0] != "" {
+		path = args[0]
+		err = config.SaveBreakpoints(path, saved)
+	} else {
+		err = config.SaveProjectBreakpoints(saved)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d breakpoints saved to %s\n", len(saved), path)
+	return nil
+}
+
+// Returns a location spec for bp that can be resolved again after the
+// program is rebuilt: the function name for breakpoints set on a
+// function, file:line otherwise.
+func breakpointLocationSpec(t *Term, bp *api.Breakpoint) string {
+	if bp.FunctionName != "" {
+		locs, err := t.client.FindLocation(api.EvalScope{-1, 0}, bp.FunctionName)
+		if err == nil && len(locs) == 1 && locs[0].PC == bp.Addr {
+			return bp.FunctionName
+		}
+	}
+	return fmt.Sprintf("%s:%d", bp.File, bp.Line)
+}
+
+func loadBreakpoints(t *Term, args ...string) error {
+	path := config.ProjectBreakpointsFilePath()
+	if len(args) > 0 && args[0] != "" {
+		path = args[0]
+	}
+	saved, err := config.LoadBreakpoints(path)
+	if err != nil {
+		return err
+	}
+	restoreBreakpoints(t, saved)
+	return nil
+}
+
+// Creates the saved breakpoints, reporting the ones that can not be
+// set without stopping.
+func restoreBreakpoints(t *Term, saved []config.SavedBreakpoint) {
+	for _, sbp := range saved {
+		requestedBp := &api.Breakpoint{
+			Tracepoint:  sbp.Tracepoint,
+			Stacktrace:  sbp.Stacktrace,
+			Goroutine:   sbp.Goroutine,
+			Variables:   sbp.Variables,
+			Commands:    sbp.Commands,
+			LogMessage:  sbp.LogMessage,
+			Cond:        sbp.Cond,
+			IgnoreCount: sbp.IgnoreCount,
+			StopEvery:   sbp.StopEvery,
+			CreatedAt:   sbp.CreatedAt,
+			Disabled:    sbp.Disabled,
+		}
+		if err := createBreakpoints(t, requestedBp, sbp.Location); err != nil {
+			fmt.Printf("Couldn't set breakpoint at %s: %s\n", sbp.Location, err)
+		}
+	}
+}
+
 func breakpoint(t *Term, args ...string) error {
 	return setBreakpoint(t, false, args...)
 }
@@ -565,6 +1268,23 @@ func setVar(t *Term, scope api.EvalScope, args ...string) error {
 	return t.client.SetVariable(scope, args[0], args[1])
 }
 
+func callFunction(t *Term, args ...string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("not enough arguments")
+	}
+	vals, err := t.client.Call(strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+	if len(vals) > 0 {
+		fmt.Println("Values returned:")
+	}
+	for _, v := range vals {
+		fmt.Printf("\t%s: %s\n", v.Name, v.Value)
+	}
+	return nil
+}
+
 func filterVariables(vars []api.Variable, filter string) []string {
 	reg, err := regexp.Compile(filter)
 	if err != nil {
@@ -726,6 +1446,16 @@ func printStack(stack []api.Stackframe, ind string) {
 }
 
 func printcontext(t *Term, state *api.DebuggerState) error {
+	for _, pid := range state.Forked {
+		fmt.Printf("Process %d forked child %d, use process %d to switch to it\n", state.Pid, pid, pid)
+	}
+	if state.ExecPath != "" {
+		fmt.Printf("Process %d is executing new program: %s\n", state.Pid, state.ExecPath)
+	}
+	printSignals(state)
+	if state.Syscall != nil {
+		printSyscall(state.Syscall)
+	}
 	if state.CurrentThread == nil {
 		fmt.Println("No current thread available")
 		return nil
@@ -739,19 +1469,52 @@ func printcontext(t *Term, state *api.DebuggerState) error {
 	if state.CurrentThread.Function != nil {
 		fn = state.CurrentThread.Function
 	}
+	if state.BreakpointInfo != nil && state.Breakpoint != nil && state.Breakpoint.LogMessage != "" {
+		fmt.Println(state.BreakpointInfo.LogMessage)
+		if state.Breakpoint.Tracepoint {
+			return nil
+		}
+	}
+	bpname := ""
+	if state.Breakpoint != nil && state.Breakpoint.Name != "" {
+		bpname = fmt.Sprintf("[%s] ", state.Breakpoint.Name)
+	}
 	if state.Breakpoint != nil && state.Breakpoint.Tracepoint {
 		var args []string
 		for _, arg := range state.CurrentThread.Function.Args {
 			args = append(args, arg.Value)
 		}
-		fmt.Printf("> %s(%s) %s:%d\n", fn.Name, strings.Join(args, ", "), shortenFilePath(state.CurrentThread.File), state.CurrentThread.Line)
+		fmt.Printf("> %s%s(%s) %s:%d\n", bpname, fn.Name, strings.Join(args, ", "), shortenFilePath(state.CurrentThread.File), state.CurrentThread.Line)
 	} else {
-		fmt.Printf("> %s() %s:%d\n", fn.Name, shortenFilePath(state.CurrentThread.File), state.CurrentThread.Line)
+		fmt.Printf("> %s%s() %s:%d\n", bpname, fn.Name, shortenFilePath(state.CurrentThread.File), state.CurrentThread.Line)
+	}
+
+	if rv := state.CurrentThread.ReturnValues; len(rv) > 0 {
+		fmt.Println("Values returned:")
+		for _, v := range rv {
+			fmt.Printf("\t%s: %s\n", v.Name, v.Value)
+		}
+	}
+
+	if wp := state.Watchpoint; wp != nil {
+		fmt.Printf("%s %d hit on %s\n", watchpointKind(wp), wp.ID, wp.Expr)
+		if wp.OldValue != wp.Value {
+			fmt.Printf("\tOld value: %s\n\tNew value: %s\n", wp.OldValue, wp.Value)
+		} else {
+			fmt.Printf("\tValue: %s\n", wp.Value)
+		}
+		if len(wp.ChangedFields) > 0 {
+			fmt.Printf("\tChanged: %s\n", strings.Join(wp.ChangedFields, ", "))
+		}
 	}
 
 	if state.BreakpointInfo != nil {
 		bpi := state.BreakpointInfo
 
+		if bpi.Panic != nil {
+			fmt.Printf("\tPanic: %s\n", bpi.Panic.Value)
+		}
+
 		if bpi.Goroutine != nil {
 			fmt.Printf("\tGoroutine %s\n", formatGoroutine(bpi.Goroutine))
 		}
@@ -2,7 +2,15 @@ package terminal
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
+
+	"github.com/derekparker/delve/config"
+	"github.com/derekparker/delve/service"
+	"github.com/derekparker/delve/service/api"
 )
 
 func TestCommandDefault(t *testing.T) {
@@ -65,3 +73,148 @@ func TestCommandThread(t *testing.T) {
 		t.Fatal("wrong command output: ", err.Error())
 	}
 }
+
+// Client keeping breakpoints in memory and returning canned stops from
+// Continue. Functions resolve to their entry and file:line specs to the
+// address 0x1000+line.
+type fakeClient struct {
+	service.Client
+	bps []*api.Breakpoint
+	// States returned by the next calls to Continue.
+	stops []*api.DebuggerState
+}
+
+func (c *fakeClient) Continue() <-chan *api.DebuggerState {
+	ch := make(chan *api.DebuggerState, 1)
+	ch <- c.stops[0]
+	c.stops = c.stops[1:]
+	close(ch)
+	return ch
+}
+
+func (c *fakeClient) FindLocation(scope api.EvalScope, loc string) ([]api.Location, error) {
+	if loc == "main.main" {
+		return []api.Location{{PC: 0x2000, File: "/src/main.go", Line: 5}}, nil
+	}
+	var line int
+	if _, err := fmt.Sscanf(filepath.Base(loc), "main.go:%d", &line); err != nil {
+		return nil, fmt.Errorf("location %q not found", loc)
+	}
+	return []api.Location{{PC: uint64(0x1000 + line), File: "/src/main.go", Line: line}}, nil
+}
+
+func (c *fakeClient) CreateBreakpoint(bp *api.Breakpoint) (*api.Breakpoint, error) {
+	nbp := *bp
+	nbp.ID = len(c.bps) + 1
+	nbp.File, nbp.Line = "/src/main.go", int(bp.Addr-0x1000)
+	if bp.Addr == 0x2000 {
+		nbp.FunctionName, nbp.Line = "main.main", 5
+	}
+	c.bps = append(c.bps, &nbp)
+	return &nbp, nil
+}
+
+func (c *fakeClient) ListBreakpoints() ([]*api.Breakpoint, error) {
+	return c.bps, nil
+}
+
+func TestSaveLoadBreakpoints(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dlv-breakpoints")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	client := &fakeClient{}
+	term := &Term{client: client}
+	requested := []*api.Breakpoint{
+		{Addr: 0x2000},
+		{
+			Addr:        0x100a,
+			Tracepoint:  true,
+			Stacktrace:  3,
+			Goroutine:   true,
+			Variables:   []string{"i"},
+			Commands:    []string{"print i"},
+			LogMessage:  "i is {i}",
+			Cond:        "i == 2",
+			IgnoreCount: 4,
+			StopEvery:   5,
+			CreatedAt:   "main.go:20",
+			Disabled:    true,
+		},
+	}
+	for _, bp := range requested {
+		if _, err := client.CreateBreakpoint(bp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected := append([]*api.Breakpoint(nil), client.bps...)
+
+	path := filepath.Join(dir, "breakpoints.yml")
+	if err := saveBreakpoints(term, path); err != nil {
+		t.Fatalf("saveBreakpoints(): %v", err)
+	}
+	saved, err := config.LoadBreakpoints(path)
+	if err != nil {
+		t.Fatalf("LoadBreakpoints(): %v", err)
+	}
+	if len(saved) != 2 || saved[0].Location != "main.main" || saved[1].Location != "/src/main.go:10" {
+		t.Fatalf("wrong saved breakpoints: %#v", saved)
+	}
+
+	loaded := &fakeClient{}
+	if err := loadBreakpoints(&Term{client: loaded}, path); err != nil {
+		t.Fatalf("loadBreakpoints(): %v", err)
+	}
+	if !reflect.DeepEqual(loaded.bps, expected) {
+		t.Fatalf("wrong loaded breakpoints:\n%#v\nexpected:\n%#v", loaded.bps, expected)
+	}
+}
+
+func TestRestoreBreakpoints(t *testing.T) {
+	client := &fakeClient{}
+	restoreBreakpoints(&Term{client: client}, []config.SavedBreakpoint{
+		{Location: "main.go:7", Cond: "x > 0"},
+		{Location: "nosuchfile.go:1"},
+		{Location: "main.main", Disabled: true},
+	})
+	// Breakpoints that can not be set are reported and skipped.
+	if len(client.bps) != 2 {
+		t.Fatalf("wrong number of breakpoints: %d", len(client.bps))
+	}
+	if bp := client.bps[0]; bp.Addr != 0x1007 || bp.Cond != "x > 0" || bp.Disabled {
+		t.Fatalf("wrong first breakpoint: %#v", bp)
+	}
+	if bp := client.bps[1]; bp.Addr != 0x2000 || !bp.Disabled {
+		t.Fatalf("wrong second breakpoint: %#v", bp)
+	}
+}
+
+func TestBreakpointCommandsContinue(t *testing.T) {
+	client := &fakeClient{
+		stops: []*api.DebuggerState{
+			{Breakpoint: &api.Breakpoint{ID: 2, Commands: []string{"record c", "continue", "record d"}}},
+			{Exited: true},
+		},
+	}
+	term := &Term{client: client, cmds: DebugCommands(client)}
+	var run []string
+	term.cmds.Register("record", func(t *Term, args ...string) error {
+		run = append(run, args[0])
+		return nil
+	}, "record")
+
+	state := &api.DebuggerState{Breakpoint: &api.Breakpoint{ID: 1, Commands: []string{"record a", "continue", "record b"}}}
+	if err := runBreakpointCommands(term, state); err != nil {
+		t.Fatalf("runBreakpointCommands(): %v", err)
+	}
+	// The commands following a continue are not run, the process is
+	// stopped somewhere else.
+	if !reflect.DeepEqual(run, []string{"a", "c"}) {
+		t.Fatalf("wrong commands run: %v", run)
+	}
+	if len(client.stops) != 0 || term.bpcmds != nil {
+		t.Fatalf("process not continued from each breakpoint: %d", len(client.stops))
+	}
+}
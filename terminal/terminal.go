@@ -22,10 +22,14 @@ const (
 
 type Term struct {
 	client service.Client
+	cmds   *Commands
 	prompt string
 	line   *liner.State
 	conf   *config.Config
 	dumb   bool
+	// Not nil while the commands of a breakpoint are running, see
+	// runBreakpointCommands.
+	bpcmds *breakpointCommands
 }
 
 func New(client service.Client, conf *config.Config) *Term {
@@ -57,6 +61,7 @@ func (t *Term) Run() (error, int) {
 	if t.conf != nil && t.conf.Aliases != nil {
 		cmds.Merge(t.conf.Aliases)
 	}
+	t.cmds = cmds
 	t.line.SetCompleter(func(line string) (c []string) {
 		for _, cmd := range cmds.cmds {
 			for _, alias := range cmd.aliases {
@@ -83,6 +88,16 @@ func (t *Term) Run() (error, int) {
 
 	t.line.ReadHistory(f)
 	f.Close()
+
+	// The project breakpoints are set whatever the way the process was
+	// started, including attach and connect.
+	if saved, err := config.LoadProjectBreakpoints(); err != nil {
+		fmt.Printf("Unable to load project breakpoints: %v.\n", err)
+	} else if len(saved) > 0 {
+		fmt.Printf("Loading breakpoints from %s\n", config.ProjectBreakpointsFilePath())
+		restoreBreakpoints(t, saved)
+	}
+
 	fmt.Println("Type 'help' for list of commands.")
 
 	var status int